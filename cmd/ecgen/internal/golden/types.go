@@ -0,0 +1,56 @@
+/*
+Copyright (C) BABEC. All rights reserved.
+Copyright (C) THL A29 Limited, a Tencent company. All rights reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package golden holds the types of the golden test of ecgen, types_easycodec.go is its expected
+// output and is compared to a fresh run by the tests of ecgen.
+package golden
+
+//go:generate go run github.com/TKOTKCh/contract-sdk-go-wasm/cmd/ecgen
+
+import "github.com/TKOTKCh/contract-sdk-go-wasm/sdk"
+
+// Order every supported field type
+//
+//ecgen:generate
+type Order struct {
+	Buyer    string          `ec:"buyer,required"`
+	Amount   int64           `ec:"amount,required"`
+	Count    int32           `ec:"count"`
+	Index    int             `ec:"index"`
+	Total    uint64          `ec:"total"`
+	Size     uint            `ec:"size"`
+	Paid     bool            `ec:"paid"`
+	Price    sdk.EasyDecimal `ec:"price"`
+	Raw      []byte          `ec:"raw"`
+	Memo     string          `ec:"memo,omitempty"`
+	Item     Item            `ec:"item"`
+	Next     *Item           `ec:"next"`
+	Untagged string
+	Skipped  int `ec:"-"`
+	internal int
+}
+
+// Item nested struct
+//
+//ecgen:generate
+type Item struct {
+	Name  string `ec:"name"`
+	Units int64  `ec:"units,omitempty"`
+}
+
+// Node self-referential struct
+//
+//ecgen:generate
+type Node struct {
+	Value int32 `ec:"value"`
+	Next  *Node `ec:"next"`
+}
+
+// NotGenerated not annotated
+type NotGenerated struct {
+	Name string
+}
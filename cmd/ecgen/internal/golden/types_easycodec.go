@@ -0,0 +1,240 @@
+// Code generated by ecgen; DO NOT EDIT.
+
+package golden
+
+import (
+	"fmt"
+
+	"github.com/TKOTKCh/contract-sdk-go-wasm/sdk"
+)
+
+// MarshalEasy serialize Item as EasyCodec user items, without header
+func (v *Item) MarshalEasy() []byte {
+	count, size := 1, 4
+	size += sdk.EasyItemSize("name", len(v.Name))
+	if v.Units != 0 {
+		count++
+		size += sdk.EasyItemSize("units", 8)
+	}
+	b := make([]byte, 0, size)
+	b = sdk.AppendEasyCount(b, count)
+	b = sdk.AppendEasyString(b, "name", v.Name)
+	if v.Units != 0 {
+		b = sdk.AppendEasyInt64(b, "units", v.Units)
+	}
+	return b
+}
+
+// UnmarshalEasy set Item to the user items of data, serialized with or without header.
+// v is zeroed first, unknown keys are ignored
+func (v *Item) UnmarshalEasy(data []byte) error {
+	return v.unmarshalEasy(data, 0)
+}
+
+// unmarshalEasy UnmarshalEasy of data nested in depth codecs, nesting over MAX_DEPTH is ErrCodec
+func (v *Item) unmarshalEasy(data []byte, depth int) error {
+	*v = Item{}
+	var s sdk.EasyScanner
+	s.Reset(data)
+	for s.Next() {
+		if s.KeyType() != sdk.EasyKeyType_USER {
+			continue
+		}
+		var err error
+		switch string(s.Key()) {
+		case "name":
+			v.Name, err = s.String()
+		case "units":
+			v.Units, err = s.Int64()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if err := s.Err(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// MarshalEasy serialize Node as EasyCodec user items, without header
+func (v *Node) MarshalEasy() []byte {
+	var nested1 []byte
+	if v.Next != nil {
+		nested1 = v.Next.MarshalEasy()
+	}
+	count, size := 1, 4
+	size += sdk.EasyItemSize("value", 4)
+	if v.Next != nil {
+		count++
+		size += sdk.EasyItemSize("next", len(nested1))
+	}
+	b := make([]byte, 0, size)
+	b = sdk.AppendEasyCount(b, count)
+	b = sdk.AppendEasyInt32(b, "value", v.Value)
+	if v.Next != nil {
+		b = sdk.AppendEasyCodec(b, "next", nested1)
+	}
+	return b
+}
+
+// UnmarshalEasy set Node to the user items of data, serialized with or without header.
+// v is zeroed first, unknown keys are ignored
+func (v *Node) UnmarshalEasy(data []byte) error {
+	return v.unmarshalEasy(data, 0)
+}
+
+// unmarshalEasy UnmarshalEasy of data nested in depth codecs, nesting over MAX_DEPTH is ErrCodec
+func (v *Node) unmarshalEasy(data []byte, depth int) error {
+	*v = Node{}
+	var s sdk.EasyScanner
+	s.Reset(data)
+	for s.Next() {
+		if s.KeyType() != sdk.EasyKeyType_USER {
+			continue
+		}
+		var err error
+		switch string(s.Key()) {
+		case "value":
+			v.Value, err = s.Int32()
+		case "next":
+			var nested []byte
+			if depth >= sdk.MAX_DEPTH {
+				err = fmt.Errorf("key %q nested over depth %d: %w", "next", sdk.MAX_DEPTH, sdk.ErrCodec)
+			} else if nested, err = s.Codec(); err == nil {
+				v.Next = new(Node)
+				err = v.Next.unmarshalEasy(nested, depth+1)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if err := s.Err(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// MarshalEasy serialize Order as EasyCodec user items, without header
+func (v *Order) MarshalEasy() []byte {
+	nested10 := v.Item.MarshalEasy()
+	var nested11 []byte
+	if v.Next != nil {
+		nested11 = v.Next.MarshalEasy()
+	}
+	count, size := 11, 4
+	size += sdk.EasyItemSize("buyer", len(v.Buyer))
+	size += sdk.EasyItemSize("amount", 8)
+	size += sdk.EasyItemSize("count", 4)
+	size += sdk.EasyItemSize("index", 8)
+	size += sdk.EasyItemSize("total", 8)
+	size += sdk.EasyItemSize("size", 8)
+	size += sdk.EasyItemSize("paid", 1)
+	size += sdk.EasyItemSize("price", len(v.Price))
+	size += sdk.EasyItemSize("raw", len(v.Raw))
+	if len(v.Memo) != 0 {
+		count++
+		size += sdk.EasyItemSize("memo", len(v.Memo))
+	}
+	size += sdk.EasyItemSize("item", len(nested10))
+	if v.Next != nil {
+		count++
+		size += sdk.EasyItemSize("next", len(nested11))
+	}
+	size += sdk.EasyItemSize("Untagged", len(v.Untagged))
+	b := make([]byte, 0, size)
+	b = sdk.AppendEasyCount(b, count)
+	b = sdk.AppendEasyString(b, "buyer", v.Buyer)
+	b = sdk.AppendEasyInt64(b, "amount", v.Amount)
+	b = sdk.AppendEasyInt32(b, "count", v.Count)
+	b = sdk.AppendEasyInt64(b, "index", int64(v.Index))
+	b = sdk.AppendEasyUint64(b, "total", v.Total)
+	b = sdk.AppendEasyUint64(b, "size", uint64(v.Size))
+	b = sdk.AppendEasyBool(b, "paid", v.Paid)
+	b = sdk.AppendEasyDecimal(b, "price", v.Price)
+	b = sdk.AppendEasyBytes(b, "raw", v.Raw)
+	if len(v.Memo) != 0 {
+		b = sdk.AppendEasyString(b, "memo", v.Memo)
+	}
+	b = sdk.AppendEasyCodec(b, "item", nested10)
+	if v.Next != nil {
+		b = sdk.AppendEasyCodec(b, "next", nested11)
+	}
+	b = sdk.AppendEasyString(b, "Untagged", v.Untagged)
+	return b
+}
+
+// UnmarshalEasy set Order to the user items of data, serialized with or without header.
+// v is zeroed first, unknown keys are ignored
+func (v *Order) UnmarshalEasy(data []byte) error {
+	return v.unmarshalEasy(data, 0)
+}
+
+// unmarshalEasy UnmarshalEasy of data nested in depth codecs, nesting over MAX_DEPTH is ErrCodec
+func (v *Order) unmarshalEasy(data []byte, depth int) error {
+	*v = Order{}
+	var seen [2]bool
+	var s sdk.EasyScanner
+	s.Reset(data)
+	for s.Next() {
+		if s.KeyType() != sdk.EasyKeyType_USER {
+			continue
+		}
+		var err error
+		switch string(s.Key()) {
+		case "buyer":
+			v.Buyer, err = s.String()
+			seen[0] = true
+		case "amount":
+			v.Amount, err = s.Int64()
+			seen[1] = true
+		case "count":
+			v.Count, err = s.Int32()
+		case "index":
+			v.Index, err = s.Int()
+		case "total":
+			v.Total, err = s.Uint64()
+		case "size":
+			v.Size, err = s.Uint()
+		case "paid":
+			v.Paid, err = s.Bool()
+		case "price":
+			v.Price, err = s.Decimal()
+		case "raw":
+			v.Raw, err = s.Bytes()
+		case "memo":
+			v.Memo, err = s.String()
+		case "item":
+			var nested []byte
+			if depth >= sdk.MAX_DEPTH {
+				err = fmt.Errorf("key %q nested over depth %d: %w", "item", sdk.MAX_DEPTH, sdk.ErrCodec)
+			} else if nested, err = s.Codec(); err == nil {
+				err = v.Item.unmarshalEasy(nested, depth+1)
+			}
+		case "next":
+			var nested []byte
+			if depth >= sdk.MAX_DEPTH {
+				err = fmt.Errorf("key %q nested over depth %d: %w", "next", sdk.MAX_DEPTH, sdk.ErrCodec)
+			} else if nested, err = s.Codec(); err == nil {
+				v.Next = new(Item)
+				err = v.Next.unmarshalEasy(nested, depth+1)
+			}
+		case "Untagged":
+			v.Untagged, err = s.String()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if err := s.Err(); err != nil {
+		return err
+	}
+	if !seen[0] {
+		return fmt.Errorf("missing required key %q: %w", "buyer", sdk.ErrCodec)
+	}
+	if !seen[1] {
+		return fmt.Errorf("missing required key %q: %w", "amount", sdk.ErrCodec)
+	}
+	return nil
+}
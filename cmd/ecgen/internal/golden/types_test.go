@@ -0,0 +1,110 @@
+/*
+Copyright (C) BABEC. All rights reserved.
+Copyright (C) THL A29 Limited, a Tencent company. All rights reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package golden
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/TKOTKCh/contract-sdk-go-wasm/sdk"
+)
+
+func order() Order {
+	return Order{
+		Buyer: "alice", Amount: -5, Count: 3, Index: -1, Total: 1 << 63, Size: 9, Paid: true,
+		Price: "1.5", Raw: []byte{0, 1}, Memo: "m", Item: Item{Name: "a", Units: 2},
+		Next: &Item{Name: "b"}, Untagged: "u",
+	}
+}
+
+func TestMarshalEasyMatchesMarshalStruct(t *testing.T) {
+	in := order()
+	ec := sdk.NewEasyCodec()
+	if err := ec.MarshalStruct(&in); err != nil {
+		t.Fatal(err)
+	}
+	var fromStruct Order
+	if err := fromStruct.UnmarshalEasy(ec.Marshal()); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fromStruct, in) {
+		t.Fatalf("UnmarshalEasy of MarshalStruct: got %+v, want %+v", fromStruct, in)
+	}
+
+	items, err := sdk.EasyUnmarshalStrict(in.MarshalEasy())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fromEasy Order
+	if err = sdk.UnmarshalStruct(sdk.NewEasyCodecWithItems(items), &fromEasy); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fromEasy, in) {
+		t.Fatalf("UnmarshalStruct of MarshalEasy: got %+v, want %+v", fromEasy, in)
+	}
+}
+
+func TestUnmarshalEasyResetsReceiver(t *testing.T) {
+	in := Order{Buyer: "bob", Amount: 1, Price: "0", Raw: []byte{}}
+	out := order()
+	out.Skipped = 7
+	if err := out.UnmarshalEasy(in.MarshalEasy()); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(out, in) {
+		t.Fatalf("got %+v, want %+v", out, in)
+	}
+}
+
+func TestUnmarshalEasyErrors(t *testing.T) {
+	var out Order
+	if err := out.UnmarshalEasy((&Item{Name: "a"}).MarshalEasy()); !errors.Is(err, sdk.ErrCodec) {
+		t.Fatalf("missing required keys: got %v, want ErrCodec", err)
+	}
+	in := order()
+	if err := out.UnmarshalEasy(append(in.MarshalEasy(), 0)); !errors.Is(err, sdk.ErrCodec) {
+		t.Fatalf("trailing byte: got %v, want ErrCodec", err)
+	}
+}
+
+// chain return n nodes linked by Next
+func chain(n int) *Node {
+	var head *Node
+	for i := 0; i < n; i++ {
+		head = &Node{Value: int32(i), Next: head}
+	}
+	return head
+}
+
+func TestUnmarshalEasyDepth(t *testing.T) {
+	// MAX_DEPTH+1 nodes nest MAX_DEPTH codecs, as deep as EasyUnmarshal goes
+	data := chain(sdk.MAX_DEPTH + 1).MarshalEasy()
+	var out Node
+	if err := out.UnmarshalEasy(data); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sdk.EasyUnmarshalStrict(data); err != nil {
+		t.Fatalf("EasyUnmarshalStrict rejects what UnmarshalEasy accepts: %v", err)
+	}
+	n := 0
+	for node := &out; node != nil; node = node.Next {
+		n++
+	}
+	if n != sdk.MAX_DEPTH+1 {
+		t.Fatalf("decoded %d nodes", n)
+	}
+
+	data = chain(sdk.MAX_DEPTH + 2).MarshalEasy()
+	if err := out.UnmarshalEasy(data); !errors.Is(err, sdk.ErrCodec) {
+		t.Fatalf("over MAX_DEPTH: got %v, want ErrCodec", err)
+	}
+	if _, err := sdk.EasyUnmarshalStrict(data); !errors.Is(err, sdk.ErrCodec) {
+		t.Fatalf("EasyUnmarshalStrict accepts what UnmarshalEasy rejects: %v", err)
+	}
+}
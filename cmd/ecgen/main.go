@@ -0,0 +1,491 @@
+/*
+Copyright (C) BABEC. All rights reserved.
+Copyright (C) THL A29 Limited, a Tencent company. All rights reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Ecgen generate allocation free MarshalEasy and UnmarshalEasy methods for structs, writing the
+// EasyCodec layout of sdk.EasyMarshal without reflection nor interface{} boxing.
+//
+// Use it with go generate, naming the types or annotating them with //ecgen:generate:
+//
+//	//go:generate go run github.com/TKOTKCh/contract-sdk-go-wasm/cmd/ecgen -type Order,Item
+//
+//	//ecgen:generate
+//	type Order struct {
+//		Buyer  string `ec:"buyer,required"`
+//		Amount int64  `ec:"amount"`
+//		Item   *Item  `ec:"item"`
+//	}
+//
+// The ec tags are the ones of sdk.MarshalStruct. Supported field types are int32, int64, int,
+// uint64, uint, bool, string, []byte, sdk.EasyDecimal and the structs generated in the same run,
+// by value or pointer. MarshalEasy does not check sdk.EasyDecimal fields, they must be valid as
+// per sdk.ParseEasyDecimal. UnmarshalEasy decodes nested structs up to sdk.MAX_DEPTH codecs, as
+// sdk.EasyUnmarshal, so a self-referential type is bounded. The methods are written to
+// <file>_easycodec.go next to $GOFILE, with an unexported unmarshalEasy method per type.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	sdkImport = "github.com/TKOTKCh/contract-sdk-go-wasm/sdk"
+	directive = "//ecgen:generate"
+)
+
+type kind int
+
+const (
+	kindInt32 kind = iota
+	kindInt64
+	kindInt
+	kindUint64
+	kindUint
+	kindBool
+	kindString
+	kindDecimal
+	kindBytes
+	kindStruct
+	kindStructPtr
+)
+
+type field struct {
+	name      string
+	key       string
+	kind      kind
+	typeName  string
+	required  bool
+	omitEmpty bool
+}
+
+type structType struct {
+	name   string
+	fields []field
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("ecgen: ")
+	typeNames := flag.String("type", "", "comma separated list of type names, default the annotated types")
+	output := flag.String("output", "", "output file name, default <$GOFILE>_easycodec.go")
+	flag.Parse()
+
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+	outName := *output
+	if outName == "" {
+		base := strings.TrimSuffix(os.Getenv("GOFILE"), ".go")
+		if base == "" {
+			base = "ecgen"
+		}
+		outName = filepath.Join(dir, base+"_easycodec.go")
+	}
+
+	var names []string
+	if *typeNames != "" {
+		names = strings.Split(*typeNames, ",")
+	}
+	src, err := generateDir(dir, names, outName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err = os.WriteFile(outName, src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// generateDir return the source of outName for the struct types names of the package in dir,
+// the annotated types when names is empty
+func generateDir(dir string, names []string, outName string) ([]byte, error) {
+	pkgName, decls, err := parseDir(dir, outName)
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		for name, decl := range decls {
+			if decl.annotated {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no type to generate, use -type or %s", directive)
+	}
+
+	types := make([]*structType, 0, len(names))
+	generated := make(map[string]bool, len(names))
+	for _, name := range names {
+		generated[name] = true
+	}
+	for _, name := range names {
+		decl, ok := decls[name]
+		if !ok {
+			return nil, fmt.Errorf("struct type %s not found in %s", name, dir)
+		}
+		t, err := newStructType(name, decl.spec, generated)
+		if err != nil {
+			return nil, err
+		}
+		types = append(types, t)
+	}
+	return generate(pkgName, types)
+}
+
+type structDecl struct {
+	spec      *ast.StructType
+	annotated bool
+}
+
+// parseDir collect the struct declarations of the package in dir, skipping tests and the output
+func parseDir(dir, outName string) (string, map[string]structDecl, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go") && info.Name() != filepath.Base(outName)
+	}, parser.ParseComments)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(pkgs) != 1 {
+		return "", nil, fmt.Errorf("%d packages found in %s, want 1", len(pkgs), dir)
+	}
+	decls := make(map[string]structDecl)
+	var pkgName string
+	for name, pkg := range pkgs {
+		pkgName = name
+		for _, file := range pkg.Files {
+			for _, d := range file.Decls {
+				gen, ok := d.(*ast.GenDecl)
+				if !ok || gen.Tok != token.TYPE {
+					continue
+				}
+				for _, s := range gen.Specs {
+					spec := s.(*ast.TypeSpec)
+					st, ok := spec.Type.(*ast.StructType)
+					if !ok {
+						continue
+					}
+					decls[spec.Name.Name] = structDecl{
+						spec:      st,
+						annotated: hasDirective(gen.Doc) || hasDirective(spec.Doc),
+					}
+				}
+			}
+		}
+	}
+	return pkgName, decls, nil
+}
+
+func hasDirective(doc *ast.CommentGroup) bool {
+	if doc == nil {
+		return false
+	}
+	for _, c := range doc.List {
+		if strings.TrimSpace(c.Text) == directive {
+			return true
+		}
+	}
+	return false
+}
+
+func newStructType(name string, st *ast.StructType, generated map[string]bool) (*structType, error) {
+	t := &structType{name: name}
+	keys := make(map[string]bool)
+	for _, f := range st.Fields.List {
+		if len(f.Names) == 0 {
+			return nil, fmt.Errorf("%s: embedded fields are not supported", name)
+		}
+		var tag string
+		if f.Tag != nil {
+			raw, err := strconv.Unquote(f.Tag.Value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid tag %s", name, f.Tag.Value)
+			}
+			tag = reflect.StructTag(raw).Get("ec")
+		}
+		if tag == "-" {
+			continue
+		}
+		k, typeName, err := fieldKind(f.Type, generated)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %v", name, f.Names[0].Name, err)
+		}
+		for _, ident := range f.Names {
+			if !ident.IsExported() {
+				continue
+			}
+			parts := strings.Split(tag, ",")
+			fd := field{name: ident.Name, key: parts[0], kind: k, typeName: typeName}
+			if fd.key == "" {
+				fd.key = ident.Name
+			}
+			for _, opt := range parts[1:] {
+				switch opt {
+				case "required":
+					fd.required = true
+				case "omitempty":
+					fd.omitEmpty = true
+				}
+			}
+			if keys[fd.key] {
+				return nil, fmt.Errorf("%s: duplicate key %q", name, fd.key)
+			}
+			keys[fd.key] = true
+			t.fields = append(t.fields, fd)
+		}
+	}
+	return t, nil
+}
+
+func fieldKind(expr ast.Expr, generated map[string]bool) (kind, string, error) {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		switch e.Name {
+		case "int32":
+			return kindInt32, "", nil
+		case "int64":
+			return kindInt64, "", nil
+		case "int":
+			return kindInt, "", nil
+		case "uint64":
+			return kindUint64, "", nil
+		case "uint":
+			return kindUint, "", nil
+		case "bool":
+			return kindBool, "", nil
+		case "string":
+			return kindString, "", nil
+		}
+		if generated[e.Name] {
+			return kindStruct, e.Name, nil
+		}
+	case *ast.StarExpr:
+		if ident, ok := e.X.(*ast.Ident); ok && generated[ident.Name] {
+			return kindStructPtr, ident.Name, nil
+		}
+	case *ast.ArrayType:
+		if ident, ok := e.Elt.(*ast.Ident); ok && e.Len == nil && ident.Name == "byte" {
+			return kindBytes, "", nil
+		}
+	case *ast.SelectorExpr:
+		if e.Sel.Name == "EasyDecimal" {
+			return kindDecimal, "", nil
+		}
+	}
+	var buf bytes.Buffer
+	_ = format.Node(&buf, token.NewFileSet(), expr)
+	return 0, "", fmt.Errorf("unsupported type %s", buf.String())
+}
+
+func generate(pkgName string, types []*structType) ([]byte, error) {
+	var buf bytes.Buffer
+	needFmt := false
+	for _, t := range types {
+		for _, f := range t.fields {
+			needFmt = needFmt || f.required || f.kind == kindStruct || f.kind == kindStructPtr
+		}
+	}
+	fmt.Fprintf(&buf, "// Code generated by ecgen; DO NOT EDIT.\n\npackage %s\n\nimport (\n", pkgName)
+	if needFmt {
+		buf.WriteString("\t\"fmt\"\n\n")
+	}
+	fmt.Fprintf(&buf, "\t%q\n)\n", sdkImport)
+	for _, t := range types {
+		writeMarshal(&buf, t)
+		writeUnmarshal(&buf, t)
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated code: %v\n%s", err, buf.Bytes())
+	}
+	return src, nil
+}
+
+// condition return the expression guarding an optional item, empty when the item is always written
+func condition(f field) string {
+	v := "v." + f.name
+	switch {
+	case f.kind == kindStructPtr:
+		return v + " != nil"
+	case !f.omitEmpty:
+		return ""
+	}
+	switch f.kind {
+	case kindInt32, kindInt64, kindInt, kindUint64, kindUint:
+		return v + " != 0"
+	case kindBool:
+		return v
+	case kindString, kindDecimal, kindBytes:
+		return "len(" + v + ") != 0"
+	}
+	return ""
+}
+
+func valueLen(f field, i int) string {
+	switch f.kind {
+	case kindInt32:
+		return "4"
+	case kindInt64, kindInt, kindUint64, kindUint:
+		return "8"
+	case kindBool:
+		return "1"
+	case kindStruct, kindStructPtr:
+		return fmt.Sprintf("len(nested%d)", i)
+	}
+	return "len(v." + f.name + ")"
+}
+
+func appendCall(f field, i int) string {
+	v := "v." + f.name
+	key := strconv.Quote(f.key)
+	switch f.kind {
+	case kindInt32:
+		return fmt.Sprintf("sdk.AppendEasyInt32(b, %s, %s)", key, v)
+	case kindInt64:
+		return fmt.Sprintf("sdk.AppendEasyInt64(b, %s, %s)", key, v)
+	case kindInt:
+		return fmt.Sprintf("sdk.AppendEasyInt64(b, %s, int64(%s))", key, v)
+	case kindUint64:
+		return fmt.Sprintf("sdk.AppendEasyUint64(b, %s, %s)", key, v)
+	case kindUint:
+		return fmt.Sprintf("sdk.AppendEasyUint64(b, %s, uint64(%s))", key, v)
+	case kindBool:
+		return fmt.Sprintf("sdk.AppendEasyBool(b, %s, %s)", key, v)
+	case kindString:
+		return fmt.Sprintf("sdk.AppendEasyString(b, %s, %s)", key, v)
+	case kindDecimal:
+		return fmt.Sprintf("sdk.AppendEasyDecimal(b, %s, %s)", key, v)
+	case kindBytes:
+		return fmt.Sprintf("sdk.AppendEasyBytes(b, %s, %s)", key, v)
+	}
+	return fmt.Sprintf("sdk.AppendEasyCodec(b, %s, nested%d)", key, i)
+}
+
+func writeMarshal(buf *bytes.Buffer, t *structType) {
+	fmt.Fprintf(buf, "\n// MarshalEasy serialize %s as EasyCodec user items, without header\n", t.name)
+	fmt.Fprintf(buf, "func (v *%s) MarshalEasy() []byte {\n", t.name)
+	for i, f := range t.fields {
+		switch f.kind {
+		case kindStruct:
+			fmt.Fprintf(buf, "nested%d := v.%s.MarshalEasy()\n", i, f.name)
+		case kindStructPtr:
+			fmt.Fprintf(buf, "var nested%d []byte\nif v.%s != nil {\nnested%d = v.%s.MarshalEasy()\n}\n",
+				i, f.name, i, f.name)
+		}
+	}
+	fixed := 0
+	for _, f := range t.fields {
+		if condition(f) == "" {
+			fixed++
+		}
+	}
+	fmt.Fprintf(buf, "count, size := %d, 4\n", fixed)
+	for i, f := range t.fields {
+		size := fmt.Sprintf("size += sdk.EasyItemSize(%q, %s)\n", f.key, valueLen(f, i))
+		if cond := condition(f); cond != "" {
+			fmt.Fprintf(buf, "if %s {\ncount++\n%s}\n", cond, size)
+		} else {
+			buf.WriteString(size)
+		}
+	}
+	buf.WriteString("b := make([]byte, 0, size)\nb = sdk.AppendEasyCount(b, count)\n")
+	for i, f := range t.fields {
+		call := fmt.Sprintf("b = %s\n", appendCall(f, i))
+		if cond := condition(f); cond != "" {
+			fmt.Fprintf(buf, "if %s {\n%s}\n", cond, call)
+		} else {
+			buf.WriteString(call)
+		}
+	}
+	buf.WriteString("return b\n}\n")
+}
+
+// depthCheck the start of the if statement decoding the nested struct of f, failing past MAX_DEPTH
+// as EasyUnmarshal does, so that a self-referential type can not recurse without bound
+func depthCheck(f field) string {
+	return fmt.Sprintf("if depth >= sdk.MAX_DEPTH {\nerr = fmt.Errorf(\"key %%q nested over depth %%d: %%w\", %q, sdk.MAX_DEPTH, sdk.ErrCodec)\n} else",
+		f.key)
+}
+
+func writeUnmarshal(buf *bytes.Buffer, t *structType) {
+	required := 0
+	for _, f := range t.fields {
+		if f.required {
+			required++
+		}
+	}
+	fmt.Fprintf(buf, "\n// UnmarshalEasy set %s to the user items of data, serialized with or without header.\n", t.name)
+	buf.WriteString("// v is zeroed first, unknown keys are ignored\n")
+	fmt.Fprintf(buf, "func (v *%s) UnmarshalEasy(data []byte) error {\nreturn v.unmarshalEasy(data, 0)\n}\n", t.name)
+	fmt.Fprintf(buf, "\n// unmarshalEasy UnmarshalEasy of data nested in depth codecs, nesting over MAX_DEPTH is ErrCodec\n")
+	fmt.Fprintf(buf, "func (v *%s) unmarshalEasy(data []byte, depth int) error {\n", t.name)
+	fmt.Fprintf(buf, "*v = %s{}\n", t.name)
+	if required > 0 {
+		fmt.Fprintf(buf, "var seen [%d]bool\n", required)
+	}
+	buf.WriteString("var s sdk.EasyScanner\ns.Reset(data)\nfor s.Next() {\n")
+	buf.WriteString("if s.KeyType() != sdk.EasyKeyType_USER {\ncontinue\n}\nvar err error\nswitch string(s.Key()) {\n")
+	seen := 0
+	for _, f := range t.fields {
+		fmt.Fprintf(buf, "case %q:\n", f.key)
+		v := "v." + f.name
+		switch f.kind {
+		case kindInt32:
+			fmt.Fprintf(buf, "%s, err = s.Int32()\n", v)
+		case kindInt64:
+			fmt.Fprintf(buf, "%s, err = s.Int64()\n", v)
+		case kindInt:
+			fmt.Fprintf(buf, "%s, err = s.Int()\n", v)
+		case kindUint64:
+			fmt.Fprintf(buf, "%s, err = s.Uint64()\n", v)
+		case kindUint:
+			fmt.Fprintf(buf, "%s, err = s.Uint()\n", v)
+		case kindBool:
+			fmt.Fprintf(buf, "%s, err = s.Bool()\n", v)
+		case kindString:
+			fmt.Fprintf(buf, "%s, err = s.String()\n", v)
+		case kindDecimal:
+			fmt.Fprintf(buf, "%s, err = s.Decimal()\n", v)
+		case kindBytes:
+			fmt.Fprintf(buf, "%s, err = s.Bytes()\n", v)
+		case kindStruct:
+			fmt.Fprintf(buf, "var nested []byte\n%s if nested, err = s.Codec(); err == nil {\nerr = %s.unmarshalEasy(nested, depth+1)\n}\n",
+				depthCheck(f), v)
+		case kindStructPtr:
+			fmt.Fprintf(buf, "var nested []byte\n%s if nested, err = s.Codec(); err == nil {\n%s = new(%s)\nerr = %s.unmarshalEasy(nested, depth+1)\n}\n",
+				depthCheck(f), v, f.typeName, v)
+		}
+		if f.required {
+			fmt.Fprintf(buf, "seen[%d] = true\n", seen)
+			seen++
+		}
+	}
+	buf.WriteString("}\nif err != nil {\nreturn err\n}\n}\nif err := s.Err(); err != nil {\nreturn err\n}\n")
+	seen = 0
+	for _, f := range t.fields {
+		if f.required {
+			fmt.Fprintf(buf, "if !seen[%d] {\nreturn fmt.Errorf(\"missing required key %%q: %%w\", %q, sdk.ErrCodec)\n}\n",
+				seen, f.key)
+			seen++
+		}
+	}
+	buf.WriteString("return nil\n}\n")
+}
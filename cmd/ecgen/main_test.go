@@ -0,0 +1,51 @@
+/*
+Copyright (C) BABEC. All rights reserved.
+Copyright (C) THL A29 Limited, a Tencent company. All rights reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden output")
+
+func TestGolden(t *testing.T) {
+	dir := filepath.Join("internal", "golden")
+	outName := filepath.Join(dir, "types_easycodec.go")
+	src, err := generateDir(dir, nil, outName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *update {
+		if err = os.WriteFile(outName, src, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(outName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(src, want) {
+		t.Fatalf("generated code differs from %s, run go test -update after checking it:\n%s", outName, src)
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	dir := filepath.Join("internal", "golden")
+	outName := filepath.Join(dir, "types_easycodec.go")
+	if _, err := generateDir(dir, []string{"Missing"}, outName); err == nil {
+		t.Fatal("missing type generated")
+	}
+	// Order refers to Item, which is not generated in this run
+	if _, err := generateDir(dir, []string{"Order"}, outName); err == nil {
+		t.Fatal("struct field of a type not generated accepted")
+	}
+}
@@ -1,6 +1,6 @@
 module github.com/TKOTKCh/contract-sdk-go-wasm
 
-go 1.16
+go 1.18
 
 require (
 	chainmaker.org/chainmaker/pb-go/v2 v2.3.0
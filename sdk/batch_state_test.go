@@ -0,0 +1,127 @@
+//go:build !wasm
+// +build !wasm
+
+/*
+Copyright (C) BABEC. All rights reserved.
+Copyright (C) THL A29 Limited, a Tencent company. All rights reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package sdk_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	vmPb "chainmaker.org/chainmaker/pb-go/v2/vm"
+
+	"github.com/TKOTKCh/contract-sdk-go-wasm/sdk"
+	"github.com/TKOTKCh/contract-sdk-go-wasm/sdk/mock"
+)
+
+// methodRecorder Host recording the method of each sys_call before passing it to the wrapped Host
+type methodRecorder struct {
+	sdk.Host
+	methods []string
+}
+
+func (h *methodRecorder) SysCall(requestHeader string, requestBody string) int32 {
+	header := sdk.NewEasyCodecWithItems(sdk.EasyUnmarshal([]byte(requestHeader)))
+	method, _ := header.GetValue("method", sdk.EasyKeyType_SYSTEM)
+	h.methods = append(h.methods, method.(string))
+	return h.Host.SysCall(requestHeader, requestBody)
+}
+
+// recordMethods make h record the sys_calls of the running mock transaction
+func recordMethods() *methodRecorder {
+	h := &methodRecorder{}
+	h.Host = sdk.SetHost(h)
+	return h
+}
+
+func TestGetBatchState(t *testing.T) {
+	chain := mock.NewChain()
+	chain.SetState("balance", "alice", []byte("100"))
+	chain.SetState("balance", "bob", []byte("5"))
+	chain.Invoke(func() {
+		h := recordMethods()
+		keys, err := sdk.GetBatchStateE([]*vmPb.BatchKey{
+			{Key: "balance", Field: "alice"},
+			{Key: "balance", Field: "carol"},
+			{Key: "balance", Field: "bob"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Join(h.methods, ","); got != sdk.ContractMethodGetBatchStateLen+","+sdk.ContractMethodGetBatchState {
+			t.Errorf("sys_calls %s", got)
+		}
+		if len(keys) != 3 || string(keys[0].Value) != "100" || keys[1].Value != nil || string(keys[2].Value) != "5" {
+			t.Fatalf("keys %v", keys)
+		}
+		if keys[1].Field != "carol" {
+			t.Errorf("the missing key lost its field: %v", keys[1])
+		}
+	}, nil)
+}
+
+func TestPutAndDeleteBatchState(t *testing.T) {
+	chain := mock.NewChain()
+	chain.SetState("balance", "alice", []byte("100"))
+	result := chain.Invoke(func() {
+		h := recordMethods()
+		err := sdk.PutBatchStateE([]*vmPb.BatchKey{
+			{Key: "balance", Field: "bob", Value: []byte("5")},
+			{Key: "balance", Field: "carol", Value: []byte("7")},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = sdk.DeleteBatchStateE([]*vmPb.BatchKey{{Key: "balance", Field: "alice"}}); err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Join(h.methods, ","); got != sdk.ContractMethodPutBatchState+","+sdk.ContractMethodDeleteBatchState {
+			t.Errorf("sys_calls %s", got)
+		}
+		keys, err := sdk.GetBatchStateE([]*vmPb.BatchKey{{Key: "balance", Field: "alice"}, {Key: "balance", Field: "carol"}})
+		if err != nil || keys[0].Value != nil || string(keys[1].Value) != "7" {
+			t.Fatalf("read back %v, %v", keys, err)
+		}
+	}, nil)
+	if result.IsError {
+		t.Fatal(result.Message)
+	}
+	if _, ok := chain.GetState("balance", "alice"); ok {
+		t.Error("alice not deleted")
+	}
+	if value, _ := chain.GetState("balance", "bob"); string(value) != "5" {
+		t.Errorf("bob %q", value)
+	}
+}
+
+func TestBatchStateLimit(t *testing.T) {
+	mock.NewChain().Invoke(func() {
+		h := recordMethods()
+		keys := make([]*vmPb.BatchKey, 10001)
+		for i := range keys {
+			keys[i] = &vmPb.BatchKey{Key: "k"}
+		}
+		if _, err := sdk.GetBatchStateE(keys); !errors.Is(err, sdk.ErrLimitExceeded) {
+			t.Errorf("get: %v", err)
+		}
+		if err := sdk.PutBatchStateE(keys); !errors.Is(err, sdk.ErrLimitExceeded) {
+			t.Errorf("put: %v", err)
+		}
+		if err := sdk.DeleteBatchStateE(keys); !errors.Is(err, sdk.ErrLimitExceeded) {
+			t.Errorf("delete: %v", err)
+		}
+		if len(h.methods) != 0 {
+			t.Errorf("sys_calls over the limit: %v", h.methods)
+		}
+		if err := sdk.PutBatchStateE(keys[:10000]); err != nil {
+			t.Errorf("put at the limit: %v", err)
+		}
+	}, nil)
+}
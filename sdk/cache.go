@@ -0,0 +1,336 @@
+/*
+Copyright (C) BABEC. All rights reserved.
+Copyright (C) THL A29 Limited, a Tencent company. All rights reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package sdk
+
+import (
+	"errors"
+
+	vmPb "chainmaker.org/chainmaker/pb-go/v2/vm"
+)
+
+// CachedSimContext SimContext with a transaction scoped state cache. Reads are memoised,
+// writes are kept in the cache and served to later reads, repeated writes of the same
+// [key, field] are coalesced, and everything is written to the chain by Flush with batch sys_calls.
+// Iterators are served by the chain, so the cache is flushed before one is created. A cross
+// contract call flushes the cache before and drops it after, as the callee may write the cached keys.
+// Values are copied in and out of the cache, changing a slice never changes a cached write.
+//
+// A Contract using UseStateCache flushes the cache after each successful method. Flush uses the
+// PutBatchState and DeleteBatchState sys_calls, see PutBatchStateE, and writes key by key on a
+// host without them.
+type CachedSimContext struct {
+	SimContextImpl
+	entries map[string]*cacheEntry
+	// dirty composite keys in order of first write
+	dirty []string
+	// noBatch the host failed a batch sys_call, Flush writes key by key
+	noBatch bool
+}
+
+type cacheEntry struct {
+	key     string
+	field   string
+	value   []byte
+	exists  bool
+	isDirty bool
+}
+
+// NewCachedSimContext create a SimContext with an empty state cache
+func NewCachedSimContext() *CachedSimContext {
+	return &CachedSimContext{entries: make(map[string]*cacheEntry)}
+}
+
+func cacheKey(key string, field string) string {
+	if field == "" {
+		return key
+	}
+	return key + "#" + field
+}
+
+func (s *CachedSimContext) get(key string, field string) (*cacheEntry, error) {
+	k := cacheKey(key, field)
+	if entry, ok := s.entries[k]; ok {
+		return entry, nil
+	}
+	value, exists, err := GetStateWithExistsE(key, field)
+	if err != nil {
+		return nil, err
+	}
+	entry := &cacheEntry{key: key, field: field, value: value, exists: exists}
+	s.entries[k] = entry
+	return entry, nil
+}
+
+func (s *CachedSimContext) put(key string, field string, value []byte, exists bool) {
+	k := cacheKey(key, field)
+	entry, ok := s.entries[k]
+	if !ok {
+		entry = &cacheEntry{key: key, field: field}
+		s.entries[k] = entry
+	}
+	if !entry.isDirty {
+		entry.isDirty = true
+		s.dirty = append(s.dirty, k)
+	}
+	entry.value = cloneBytes(value)
+	entry.exists = exists
+}
+
+// drop forget every entry, the dirty ones must have been flushed
+func (s *CachedSimContext) drop() {
+	s.entries = make(map[string]*cacheEntry)
+}
+
+func cloneBytes(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	return append(make([]byte, 0, len(b)), b...)
+}
+
+// Flush write the dirty entries to the chain, puts and deletes are sent as batches
+func (s *CachedSimContext) Flush() ResultCode {
+	return resultCode(s.FlushE())
+}
+
+// FlushE write the dirty entries to the chain, puts and deletes are sent as batches. When the host
+// fails a batch sys_call with *ErrHostCall, as a ChainMaker host without them does, the entries are
+// written key by key with PutStateByteE and DeleteStateE, and so are the ones of the later flushes
+func (s *CachedSimContext) FlushE() error {
+	puts := make([]*vmPb.BatchKey, 0, len(s.dirty))
+	deletes := make([]*vmPb.BatchKey, 0)
+	for _, k := range s.dirty {
+		entry := s.entries[k]
+		batchKey := &vmPb.BatchKey{Key: entry.key, Field: entry.field}
+		if entry.exists {
+			batchKey.Value = entry.value
+			puts = append(puts, batchKey)
+		} else {
+			deletes = append(deletes, batchKey)
+		}
+	}
+	err := s.writeBatches(puts, PutBatchStateE, func(k *vmPb.BatchKey) error {
+		return PutStateByteE(k.Key, k.Field, k.Value)
+	})
+	if err != nil {
+		return err
+	}
+	err = s.writeBatches(deletes, DeleteBatchStateE, func(k *vmPb.BatchKey) error {
+		return DeleteStateE(k.Key, k.Field)
+	})
+	if err != nil {
+		return err
+	}
+	for _, k := range s.dirty {
+		s.entries[k].isDirty = false
+	}
+	s.dirty = s.dirty[:0]
+	return nil
+}
+
+// writeBatches write keys with batch, defaultLimitKeys at a time, or with single once the host
+// failed a batch sys_call
+func (s *CachedSimContext) writeBatches(keys []*vmPb.BatchKey, batch func([]*vmPb.BatchKey) error,
+	single func(*vmPb.BatchKey) error) error {
+	for len(keys) > 0 && !s.noBatch {
+		n := minInt(len(keys), defaultLimitKeys)
+		err := batch(keys[:n])
+		var hostErr *ErrHostCall
+		if errors.As(err, &hostErr) {
+			s.noBatch = true
+			break
+		}
+		if err != nil {
+			return err
+		}
+		keys = keys[n:]
+	}
+	for _, k := range keys {
+		if err := single(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func (s *CachedSimContext) GetState(key string, field string) (string, ResultCode) {
+	value, code := s.GetStateByte(key, field)
+	return string(value), code
+}
+func (s *CachedSimContext) GetStateByte(key string, field string) ([]byte, ResultCode) {
+	entry, err := s.get(key, field)
+	if err != nil {
+		return nil, ERROR
+	}
+	return cloneBytes(entry.value), SUCCESS
+}
+func (s *CachedSimContext) GetStateWithExists(key, field string) (string, bool, ResultCode) {
+	entry, err := s.get(key, field)
+	if err != nil {
+		return "", false, ERROR
+	}
+	return string(entry.value), entry.exists, SUCCESS
+}
+func (s *CachedSimContext) GetStateWithExistsE(key string, field string) ([]byte, bool, error) {
+	entry, err := s.get(key, field)
+	if err != nil {
+		return nil, false, err
+	}
+	return cloneBytes(entry.value), entry.exists, nil
+}
+func (s *CachedSimContext) GetStateFromKey(key string) ([]byte, ResultCode) {
+	return s.GetStateByte(key, "")
+}
+func (s *CachedSimContext) GetStateFromKeyWithExists(key string) (string, bool, ResultCode) {
+	return s.GetStateWithExists(key, "")
+}
+func (s *CachedSimContext) GetStateFromKeyByte(key string) ([]byte, ResultCode) {
+	return s.GetStateByte(key, "")
+}
+func (s *CachedSimContext) GetBatchState(batchKeys []*vmPb.BatchKey) ([]*vmPb.BatchKey, ResultCode) {
+	if err := batchKeysLimit(batchKeys); err != nil {
+		return nil, ERROR
+	}
+	missing := make([]*vmPb.BatchKey, 0)
+	for _, batchKey := range batchKeys {
+		if _, ok := s.entries[cacheKey(batchKey.Key, batchKey.Field)]; !ok {
+			missing = append(missing, &vmPb.BatchKey{Key: batchKey.Key, Field: batchKey.Field})
+		}
+	}
+	if len(missing) > 0 {
+		values, err := GetBatchStateE(missing)
+		if err != nil {
+			return nil, ERROR
+		}
+		for _, v := range values {
+			// a batch value can not tell an empty value from a missing key, only
+			// non-empty values are cached so GetStateWithExists still asks the chain
+			if len(v.Value) > 0 {
+				s.entries[cacheKey(v.Key, v.Field)] = &cacheEntry{key: v.Key, field: v.Field, value: v.Value, exists: true}
+			}
+		}
+	}
+	result := make([]*vmPb.BatchKey, 0, len(batchKeys))
+	for _, batchKey := range batchKeys {
+		var value []byte
+		if entry, ok := s.entries[cacheKey(batchKey.Key, batchKey.Field)]; ok {
+			value = cloneBytes(entry.value)
+		}
+		result = append(result, &vmPb.BatchKey{
+			Key:          batchKey.Key,
+			Field:        batchKey.Field,
+			Value:        value,
+			ContractName: batchKey.ContractName,
+		})
+	}
+	return result, SUCCESS
+}
+
+func (s *CachedSimContext) PutState(key string, field string, value string) ResultCode {
+	return s.PutStateByte(key, field, []byte(value))
+}
+func (s *CachedSimContext) PutStateByte(key string, field string, value []byte) ResultCode {
+	s.put(key, field, value, true)
+	return SUCCESS
+}
+func (s *CachedSimContext) PutStateByteE(key string, field string, value []byte) error {
+	s.put(key, field, value, true)
+	return nil
+}
+func (s *CachedSimContext) PutStateFromKey(key string, value string) ResultCode {
+	return s.PutStateByte(key, "", []byte(value))
+}
+func (s *CachedSimContext) PutStateFromKeyByte(key string, value []byte) ResultCode {
+	return s.PutStateByte(key, "", value)
+}
+func (s *CachedSimContext) PutBatchState(batchKeys []*vmPb.BatchKey) ResultCode {
+	if err := batchKeysLimit(batchKeys); err != nil {
+		return ERROR
+	}
+	for _, batchKey := range batchKeys {
+		s.put(batchKey.Key, batchKey.Field, batchKey.Value, true)
+	}
+	return SUCCESS
+}
+func (s *CachedSimContext) DeleteState(key string, field string) ResultCode {
+	s.put(key, field, nil, false)
+	return SUCCESS
+}
+func (s *CachedSimContext) DeleteStateE(key string, field string) error {
+	s.put(key, field, nil, false)
+	return nil
+}
+func (s *CachedSimContext) DeleteStateFromKey(key string) ResultCode {
+	return s.DeleteState(key, "")
+}
+func (s *CachedSimContext) DeleteBatchState(batchKeys []*vmPb.BatchKey) ResultCode {
+	if err := batchKeysLimit(batchKeys); err != nil {
+		return ERROR
+	}
+	for _, batchKey := range batchKeys {
+		s.put(batchKey.Key, batchKey.Field, nil, false)
+	}
+	return SUCCESS
+}
+
+func (s *CachedSimContext) CallContract(contractName string, method string, param map[string][]byte) ([]byte, ResultCode) {
+	if err := s.FlushE(); err != nil {
+		return nil, ERROR
+	}
+	defer s.drop()
+	return s.SimContextImpl.CallContract(contractName, method, param)
+}
+func (s *CachedSimContext) GetTxInfo(txId string) ([]byte, ResultCode) {
+	if err := s.FlushE(); err != nil {
+		return nil, ERROR
+	}
+	defer s.drop()
+	return s.SimContextImpl.GetTxInfo(txId)
+}
+
+func (s *CachedSimContext) NewIterator(startKey string, limitKey string) (ResultSetKV, ResultCode) {
+	if err := s.FlushE(); err != nil {
+		return nil, ERROR
+	}
+	return s.SimContextImpl.NewIterator(startKey, limitKey)
+}
+func (s *CachedSimContext) NewIteratorWithField(key string, startField string, limitField string) (ResultSetKV, ResultCode) {
+	if err := s.FlushE(); err != nil {
+		return nil, ERROR
+	}
+	return s.SimContextImpl.NewIteratorWithField(key, startField, limitField)
+}
+func (s *CachedSimContext) NewIteratorWithOptions(startKey string, startField string, limitKey string, limitField string,
+	opts IteratorOptions) (ResultSetKV, ResultCode) {
+	if err := s.FlushE(); err != nil {
+		return nil, ERROR
+	}
+	return s.SimContextImpl.NewIteratorWithOptions(startKey, startField, limitKey, limitField, opts)
+}
+func (s *CachedSimContext) NewIteratorPrefixWithKeyField(key string, field string) (ResultSetKV, ResultCode) {
+	if err := s.FlushE(); err != nil {
+		return nil, ERROR
+	}
+	return s.SimContextImpl.NewIteratorPrefixWithKeyField(key, field)
+}
+func (s *CachedSimContext) NewIteratorPrefixWithKey(key string) (ResultSetKV, ResultCode) {
+	return s.NewIteratorPrefixWithKeyField(key, "")
+}
+func (s *CachedSimContext) NewHistoryKvIterForKey(startKey string, startField string) (KeyHistoryKvIter, ResultCode) {
+	if err := s.FlushE(); err != nil {
+		return nil, ERROR
+	}
+	return s.SimContextImpl.NewHistoryKvIterForKey(startKey, startField)
+}
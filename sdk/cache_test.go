@@ -0,0 +1,81 @@
+//go:build !wasm
+// +build !wasm
+
+/*
+Copyright (C) BABEC. All rights reserved.
+Copyright (C) THL A29 Limited, a Tencent company. All rights reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package sdk
+
+import (
+	"bytes"
+	"testing"
+)
+
+func methods(t *testing.T, calls []recordedCall) []string {
+	t.Helper()
+	names := make([]string, 0, len(calls))
+	for _, call := range calls {
+		names = append(names, call.method(t))
+	}
+	return names
+}
+
+func TestCacheFlushesBeforeCallContract(t *testing.T) {
+	h := &fakeHost{}
+	withFakeHost(t, h)
+
+	ctx := NewCachedSimContext()
+	ctx.PutState("balance", "alice", "100")
+	if len(h.calls) != 0 {
+		t.Fatalf("put reached the chain: %v", methods(t, h.calls))
+	}
+	if _, code := ctx.CallContract("other", "get", nil); code != SUCCESS {
+		t.Fatalf("call contract %d", code)
+	}
+	got := methods(t, h.calls)
+	if len(got) != 2 || got[0] != ContractMethodPutBatchState || got[1] != ContractMethodCallContractLen {
+		t.Fatalf("sys_calls %v, want the flush before the call", got)
+	}
+}
+
+func TestCacheDroppedAfterCallContract(t *testing.T) {
+	h := &fakeHost{value: []byte("1")}
+	withFakeHost(t, h)
+
+	ctx := NewCachedSimContext()
+	if value, _ := ctx.GetState("balance", "alice"); value != "1" {
+		t.Fatalf("got %q", value)
+	}
+	h.value = nil
+	ctx.CallContract("other", "transfer", nil)
+	h.value = []byte("2")
+	h.calls = nil
+	if value, _ := ctx.GetState("balance", "alice"); value != "2" {
+		t.Fatalf("got %q, the cache kept the value read before the call", value)
+	}
+	if got := methods(t, h.calls); len(got) == 0 || got[0] != ContractMethodGetStateLen {
+		t.Fatalf("sys_calls %v, want a read from the chain", got)
+	}
+}
+
+func TestCacheCopiesValues(t *testing.T) {
+	h := &fakeHost{}
+	withFakeHost(t, h)
+
+	ctx := NewCachedSimContext()
+	value := []byte("100")
+	ctx.PutStateByte("balance", "alice", value)
+	value[0] = '9'
+	got, _ := ctx.GetStateByte("balance", "alice")
+	if !bytes.Equal(got, []byte("100")) {
+		t.Fatalf("got %q, the cached write follows the caller slice", got)
+	}
+	got[0] = '9'
+	if again, _ := ctx.GetStateByte("balance", "alice"); !bytes.Equal(again, []byte("100")) {
+		t.Fatalf("got %q, the cached write follows the returned slice", again)
+	}
+}
@@ -13,7 +13,6 @@ import (
 	vmPb "chainmaker.org/chainmaker/pb-go/v2/vm"
 	"fmt"
 	"strconv"
-	"unsafe"
 )
 
 type ResultCode int
@@ -30,6 +29,7 @@ const (
 	ContractParamTxId         = "__tx_id__"
 	ContractParamContextPtr   = "__context_ptr__"
 	ContractParamTxTimeStamp  = "__tx_time_stamp__"
+	ContractParamMethod       = "__method__" // the method called through invoke_contract, see InvokeContract
 
 	// method name used by smart contract sdk
 	// common
@@ -53,6 +53,8 @@ const (
 	ContractMethodDeleteState      = "DeleteState"
 	ContractMethodGetBatchStateLen = "GetBatchStateLen"
 	ContractMethodGetBatchState    = "GetBatchState"
+	ContractMethodPutBatchState    = "PutBatchState"
+	ContractMethodDeleteBatchState = "DeleteBatchState"
 
 	//address
 	ContractMethodSenderAddress    = "GetSenderAddress"
@@ -92,17 +94,6 @@ const (
 	defaultLimitKeys = 10000
 )
 
-// sysCall provides data interaction with the chain. sysCallReq common param, request var param
-//
-//go:wasmimport env sys_call
-func sysCall(requestHeader string, requestBody string) int32
-
-//go:wasmimport env log_message
-func logMessage(msg string)
-
-//go:wasmimport env log_message_with_type
-func logMessageWithType(msg string, msgType int32)
-
 // SimContextCommon common context
 type SimContextCommon interface {
 	// Arg get arg from transaction parameters, as:  arg1, code := ctx.Arg("arg1")
@@ -248,6 +239,46 @@ type SimContext interface {
 	NewHistoryKvIterForKey(startKey string, startField string) (KeyHistoryKvIter, ResultCode)
 }
 
+// BatchStateContext SimContext writing batches of keys in one sys_call, implemented by
+// SimContextImpl and CachedSimContext. It is apart from SimContext, so the implementations of
+// SimContext outside of the sdk need not implement it. See PutBatchStateE for the host contract
+type BatchStateContext interface {
+	SimContext
+	// PutBatchState put [BatchKeys] to chain in one sys_call
+	// @param batchKeys: 写入的key, field, value
+	// @return: ResultCode
+	PutBatchState(batchKeys []*vmPb.BatchKey) ResultCode
+	// DeleteBatchState delete [BatchKeys] to chain in one sys_call
+	// @param batchKeys: 删除的key, field
+	// @return: ResultCode
+	DeleteBatchState(batchKeys []*vmPb.BatchKey) ResultCode
+}
+
+// StateContextE SimContext whose state accessors return the error of the sys_call, implemented by
+// SimContextImpl and CachedSimContext. It is apart from SimContext, so the implementations of
+// SimContext outside of the sdk need not implement it
+type StateContextE interface {
+	SimContext
+	// GetStateWithExistsE get [key, field] with whether it exists, see GetStateWithExistsE
+	GetStateWithExistsE(key string, field string) ([]byte, bool, error)
+	// PutStateByteE put [key, field, value], see PutStateByteE
+	PutStateByteE(key string, field string, value []byte) error
+	// DeleteStateE delete [key, field], see DeleteStateE
+	DeleteStateE(key string, field string) error
+}
+
+// IteratorOptionsContext SimContext creating iterators with IteratorOptions, implemented by
+// SimContextImpl and CachedSimContext. It is apart from SimContext, so the implementations of
+// SimContext outside of the sdk need not implement it
+type IteratorOptionsContext interface {
+	SimContext
+	// NewIteratorWithOptions range of [startKey+"#"+startField, limitKey+"#"+limitField), front closed
+	// back open, in the order and bounds of opts, see NewIteratorWithOptionsE. Descending on a host
+	// that does not support the options is ERROR, where NewIteratorWithOptionsE returns ErrUnsupported
+	NewIteratorWithOptions(startKey string, startField string, limitKey string, limitField string,
+		opts IteratorOptions) (ResultSetKV, ResultCode)
+}
+
 type SimContextCommonImpl struct {
 	origin string
 }
@@ -266,11 +297,8 @@ func (s *SimContextImpl) GetStateByte(key string, field string) ([]byte, ResultC
 	return GetStateByte(key, field)
 }
 func (s *SimContextImpl) GetStateWithExists(key, field string) (string, bool, ResultCode) {
-	value, err := GetStateByte(key, field)
-	if err != SUCCESS || value == nil {
-		return "", false, ERROR
-	}
-	return string(value), true, ERROR
+	value, exists, err := GetStateWithExistsE(key, field)
+	return string(value), exists, resultCode(err)
 }
 func (s *SimContextImpl) GetStateFromKey(key string) ([]byte, ResultCode) {
 	return GetStateByte(key, "")
@@ -303,31 +331,23 @@ func (s *SimContextImpl) DeleteStateFromKey(key string) ResultCode {
 	return DeleteState(key, "")
 }
 func (s *SimContextImpl) GetBatchState(batchKeys []*vmPb.BatchKey) ([]*vmPb.BatchKey, ResultCode) {
-	if err := s.batchKeysLimit(batchKeys); err != nil {
-		return nil, ERROR
-	}
-	getBatchStateKeys := vmPb.BatchKeys{Keys: batchKeys}
-	getBatchStateKeysByte, err := getBatchStateKeys.Marshal()
-	if err != nil {
-		return nil, ERROR
-	}
-	ec := NewEasyCodec()
-	ec.AddBytes("BatchKeys", getBatchStateKeysByte)
-	value, code := GetBytesFromChain(ec, ContractMethodSenderAddressLen, ContractMethodSenderAddress)
-	if code != SUCCESS {
-		return nil, code
-	}
-	keys := &vmPb.BatchKeys{}
-	if err = keys.Unmarshal(value); err != nil {
-		return nil, ERROR
-	}
-	return keys.Keys, SUCCESS
+	keys, err := GetBatchStateE(batchKeys)
+	return keys, resultCode(err)
 }
-func (s *SimContextImpl) batchKeysLimit(keys []*vmPb.BatchKey) error {
-	if len(keys) > defaultLimitKeys {
-		return fmt.Errorf("over batch keys count limit %d", defaultLimitKeys)
-	}
-	return nil
+func (s *SimContextImpl) GetStateWithExistsE(key string, field string) ([]byte, bool, error) {
+	return GetStateWithExistsE(key, field)
+}
+func (s *SimContextImpl) PutStateByteE(key string, field string, value []byte) error {
+	return PutStateByteE(key, field, value)
+}
+func (s *SimContextImpl) DeleteStateE(key string, field string) error {
+	return DeleteStateE(key, field)
+}
+func (s *SimContextImpl) PutBatchState(batchKeys []*vmPb.BatchKey) ResultCode {
+	return resultCode(PutBatchStateE(batchKeys))
+}
+func (s *SimContextImpl) DeleteBatchState(batchKeys []*vmPb.BatchKey) ResultCode {
+	return resultCode(DeleteBatchStateE(batchKeys))
 }
 
 // common
@@ -348,13 +368,13 @@ func (s *SimContextCommonImpl) CallContract(contractName string, method string,
 	return CallContract(contractName, method, param)
 }
 func (s *SimContextCommonImpl) SuccessResult(msg string) {
-	sysCall(getRequestHeader(ContractMethodSuccessResult), msg)
+	SuccessResult(msg)
 }
 func (s *SimContextCommonImpl) SuccessResultByte(msg []byte) {
-	sysCall(getRequestHeader(ContractMethodSuccessResult), string(msg))
+	SuccessResultByte(msg)
 }
 func (s *SimContextCommonImpl) ErrorResult(msg string) {
-	sysCall(getRequestHeader(ContractMethodErrorResult), string(msg))
+	ErrorResult(msg)
 }
 func (s *SimContextCommonImpl) GetCreatorOrgId() (string, ResultCode) {
 	return stringArg(ContractParamCreatorOrgId)
@@ -439,35 +459,35 @@ var argsBytes []byte
 var argsMap []*EasyCodecItem
 var argsFlag bool
 
-//go:wasmexport runtime_type
-func runtimeType() int32 {
-	var ContractRuntimeGoSdkType int32 = 4
-	argsFlag = false
-	return ContractRuntimeGoSdkType
-}
+// requestHeaders the sys_call headers by method, they only depend on the ctx_ptr arg
+var requestHeaders map[string]string
 
-//go:wasmexport deallocate
-func deallocate(size int32) {
-	argsBytes = make([]byte, size)
-	argsMap = make([]*EasyCodecItem, 0)
-	argsFlag = false
-}
+// bodyEncoder the body of the sys_calls that do not run contract code, as PutState.
+// Its buffer is handed to the host without copying and reset by the next sys_call,
+// so the host must not retain the body, see Host.SysCall
+var bodyEncoder = NewEncoder(256)
 
-//go:wasmexport allocate
-func allocate(size int32) uintptr {
-	argsBytes = make([]byte, size)
+// resetArgs drop the args of the previous call and what was derived from them
+func resetArgs() {
 	argsMap = make([]*EasyCodecItem, 0)
 	argsFlag = false
-
-	return uintptr(unsafe.Pointer(&argsBytes[0]))
+	requestHeaders = nil
 }
 
 func getRequestHeader(method string) string {
-	ec := NewEasyCodec()
-	ec.AddValue(EasyKeyType_SYSTEM, "ctx_ptr", EasyValueType_INT32, getCtxPtr())
-	ec.AddValue(EasyKeyType_SYSTEM, "version", EasyValueType_STRING, "v1.2.0")
-	ec.AddValue(EasyKeyType_SYSTEM, "method", EasyValueType_STRING, method)
-	return string(ec.Marshal())
+	if header, ok := requestHeaders[method]; ok {
+		return header
+	}
+	enc := NewEncoder(64)
+	enc.addSystemInt32("ctx_ptr", getCtxPtr())
+	enc.addSystemString("version", "v1.2.0")
+	enc.addSystemString("method", method)
+	header := string(enc.Bytes())
+	if requestHeaders == nil {
+		requestHeaders = make(map[string]string)
+	}
+	requestHeaders[method] = header
+	return header
 }
 
 // LogMessage
@@ -499,73 +519,126 @@ func getMessage(template string, fmtArgs []interface{}) string {
 
 // GetSenderAddress get senderAddress from chain
 func GetSenderAddress() (string, ResultCode) {
+	result, err := GetSenderAddressE()
+	return result, resultCode(err)
+}
+
+// GetSenderAddressE get senderAddress from chain
+func GetSenderAddressE() (string, error) {
 	ec := NewEasyCodec()
-	result, code := GetBytesFromChain(ec, ContractMethodSenderAddressLen, ContractMethodSenderAddress)
-	if code != SUCCESS {
-		return "", code
+	result, err := GetBytesFromChainE(ec, ContractMethodSenderAddressLen, ContractMethodSenderAddress)
+	if err != nil {
+		return "", err
 	}
-	return string(result), code
+	return string(result), nil
 }
 
 // GetState get state from chain
 func GetState(key string, field string) (string, ResultCode) {
-	result, code := GetStateByte(key, field)
-	if code != SUCCESS {
-		return "", code
+	result, err := GetStateE(key, field)
+	return result, resultCode(err)
+}
+
+// GetStateE get state from chain
+func GetStateE(key string, field string) (string, error) {
+	result, err := GetStateByteE(key, field)
+	if err != nil {
+		return "", err
 	}
-	return string(result), code
+	return string(result), nil
 }
 
 // GetState get state from chain
 func GetStateByte(key string, field string) ([]byte, ResultCode) {
+	result, err := GetStateByteE(key, field)
+	return result, resultCode(err)
+}
+
+// GetStateByteE get state from chain
+func GetStateByteE(key string, field string) ([]byte, error) {
 	ec := NewEasyCodec()
 	ec.AddString("key", key)
 	ec.AddString("field", field)
-	return GetBytesFromChain(ec, ContractMethodGetStateLen, ContractMethodGetState)
+	return GetBytesFromChainE(ec, ContractMethodGetStateLen, ContractMethodGetState)
+}
+
+// GetStateWithExistsE get state from chain with whether [key, field] exists, the value of
+// an existing key is never nil even when empty.
+//
+// The GetStateLen request carries exists_ptr, the host writes 1 or 0 as le int32 through it.
+// A host that does not know exists_ptr leaves -1, the key then exists when the value is not empty:
+// on such a host an existing key holding an empty value is reported as missing, and
+// CachedSimContext, the typed getters and package collections, reading through it, inherit that
+func GetStateWithExistsE(key string, field string) ([]byte, bool, error) {
+	var exists int32 = -1
+	ec := NewEasyCodec()
+	ec.AddString("key", key)
+	ec.AddString("field", field)
+	ec.AddInt32("exists_ptr", int32Ptr(&exists))
+	value, err := GetBytesFromChainE(ec, ContractMethodGetStateLen, ContractMethodGetState)
+	if err != nil {
+		return nil, false, err
+	}
+	switch exists {
+	case 0:
+		return nil, false, nil
+	case 1:
+		if value == nil {
+			value = []byte{}
+		}
+		return value, true, nil
+	}
+	return value, len(value) > 0, nil
 }
 
 func GetBytesFromChain(ec *EasyCodec, methodLen string, method string) ([]byte, ResultCode) {
+	result, err := GetBytesFromChainE(ec, methodLen, method)
+	return result, resultCode(err)
+}
+
+// GetBytesFromChainE get bytes from chain with a len sys_call followed by a data sys_call,
+// the failing one is returned as *ErrHostCall
+func GetBytesFromChainE(ec *EasyCodec, methodLen string, method string) ([]byte, error) {
 	// # get len
 	// ## prepare param
 	var valueLen int32 = 0
-	valuePtr := int32(uintptr(unsafe.Pointer(&valueLen)))
-	ec.AddInt32("value_ptr", valuePtr)
-	b := ec.Marshal()
+	valuePtr := int32Ptr(&valueLen)
+	ec.SetInt32("value_ptr", valuePtr)
 	// ## send req get len
-	code := sysCall(getRequestHeader(methodLen), string(b))
-	// ## verify
-	if code != int32(SUCCESS) {
-		return nil, ERROR
+	if err := hostCall(methodLen, ec.Marshal()); err != nil {
+		return nil, err
 	}
 	if valueLen == 0 {
-		return nil, SUCCESS
+		return nil, nil
 	}
 	// # get data
 	// ## prepare param
 	valueByte := make([]byte, valueLen)
-	ec.RemoveKey("value_ptr")
-	valuePtr = int32(uintptr(unsafe.Pointer(&valueByte[0])))
-	ec.AddInt32("value_ptr", valuePtr)
-	b = ec.Marshal()
+	valuePtr = ptrOf(valueByte)
+	ec.SetInt32("value_ptr", valuePtr)
 	// ## send req get value
-	code2 := sysCall(getRequestHeader(method), string(b))
-	if code2 != int32(SUCCESS) {
-		return nil, ERROR
+	if err := hostCall(method, ec.Marshal()); err != nil {
+		return nil, err
 	}
-	return valueByte, SUCCESS
+	return valueByte, nil
 }
 
 // GetInt32FromChain get i32 from chain
 func GetInt32FromChain(ec *EasyCodec, method string) (int32, ResultCode) {
+	value, err := GetInt32FromChainE(ec, method)
+	return value, resultCode(err)
+}
+
+// GetInt32FromChainE get i32 from chain
+func GetInt32FromChainE(ec *EasyCodec, method string) (int32, error) {
 	// # get len
 	// ## prepare param
 	var valueLen int32 = 0
-	valuePtr := int32(uintptr(unsafe.Pointer(&valueLen)))
-	ec.AddInt32("value_ptr", valuePtr)
-	b := ec.Marshal()
+	valuePtr := int32Ptr(&valueLen)
+	ec.SetInt32("value_ptr", valuePtr)
 	// ## send req get len
-	code := sysCall(getRequestHeader(method), string(b))
-	return valueLen, ResultCode(code)
+	err := hostCall(method, ec.Marshal())
+	return valueLen, err
 }
 
 // GetStateFromKey get state from chain
@@ -575,52 +648,45 @@ func GetStateFromKey(key string) ([]byte, ResultCode) {
 
 // EmitEvent emit Event to chain
 func EmitEvent(topic string, data ...string) ResultCode {
+	return resultCode(EmitEventE(topic, data...))
+}
+
+// EmitEventE emit Event to chain
+func EmitEventE(topic string, data ...string) error {
 	// prepare param
-	var items []*EasyCodecItem
-	items = make([]*EasyCodecItem, 0)
-	items = append(items, &EasyCodecItem{
-		KeyType:   EasyKeyType_USER,
-		Key:       "topic",
-		ValueType: EasyValueType_STRING,
-		Value:     topic,
-	})
+	bodyEncoder.Reset()
+	bodyEncoder.AddString("topic", topic)
 	for index, value := range data {
-		items = append(items, &EasyCodecItem{
-			KeyType:   EasyKeyType_USER,
-			Key:       "data" + strconv.FormatInt(int64(index), 10),
-			ValueType: EasyValueType_STRING,
-			Value:     value,
-		})
-	}
-	b := EasyMarshal(items)
-	reqBody := string(b)
-	// send req put value
-	code := sysCall(getRequestHeader(ContractMethodEmitEvent), reqBody)
-	if code != int32(SUCCESS) {
-		return ERROR
+		bodyEncoder.AddString("data"+strconv.FormatInt(int64(index), 10), value)
 	}
-	return SUCCESS
+	// send req put value
+	return hostCall(ContractMethodEmitEvent, bodyEncoder.Bytes())
 }
 
 // PutState put state to chain
 func PutState(key string, field string, value string) ResultCode {
-	// prepare param
-	ec := NewEasyCodec()
-	ec.AddString("key", key)
-	ec.AddString("field", field)
-	ec.AddBytes("value", []byte(value))
-	b := ec.Marshal()
-	// send req put value
-	code := sysCall(getRequestHeader(ContractMethodPutState), string(b))
-	if code != int32(SUCCESS) {
-		return ERROR
-	}
-	return SUCCESS
+	return resultCode(PutStateByteE(key, field, []byte(value)))
+}
+
+// PutStateE put state to chain
+func PutStateE(key string, field string, value string) error {
+	return PutStateByteE(key, field, []byte(value))
 }
 
 // PutState put state to chain
 func PutStateByte(key string, field string, value []byte) ResultCode {
-	return PutState(key, field, string(value))
+	return resultCode(PutStateByteE(key, field, value))
+}
+
+// PutStateByteE put state to chain
+func PutStateByteE(key string, field string, value []byte) error {
+	// prepare param
+	bodyEncoder.Reset()
+	bodyEncoder.AddString("key", key)
+	bodyEncoder.AddString("field", field)
+	bodyEncoder.AddBytes("value", value)
+	// send req put value
+	return hostCall(ContractMethodPutState, bodyEncoder.Bytes())
 }
 
 // PutStateFromKey put state to chain
@@ -635,25 +701,96 @@ func PutStateFromKeyByte(key string, value []byte) ResultCode {
 
 // DeleteState delete state to chain
 func DeleteState(key string, field string) ResultCode {
+	return resultCode(DeleteStateE(key, field))
+}
+
+// DeleteStateE delete state to chain
+func DeleteStateE(key string, field string) error {
 	// prepare param
-	ec := NewEasyCodec()
-	ec.AddString("key", key)
-	ec.AddString("field", field)
-	b := ec.Marshal()
+	bodyEncoder.Reset()
+	bodyEncoder.AddString("key", key)
+	bodyEncoder.AddString("field", field)
 	// send req put value
-	code := sysCall(getRequestHeader(ContractMethodDeleteState), string(b))
-	if code != int32(SUCCESS) {
-		return ERROR
+	return hostCall(ContractMethodDeleteState, bodyEncoder.Bytes())
+}
+
+// GetBatchStateE get [BatchKeys] from chain, the returned keys carry the values.
+// More than defaultLimitKeys keys is ErrLimitExceeded
+func GetBatchStateE(batchKeys []*vmPb.BatchKey) ([]*vmPb.BatchKey, error) {
+	ec, err := batchKeysCodec(batchKeys)
+	if err != nil {
+		return nil, err
+	}
+	value, err := GetBytesFromChainE(ec, ContractMethodGetBatchStateLen, ContractMethodGetBatchState)
+	if err != nil {
+		return nil, err
+	}
+	keys := &vmPb.BatchKeys{}
+	if err = keys.Unmarshal(value); err != nil {
+		return nil, fmt.Errorf("unmarshal batch keys: %v: %w", err, ErrCodec)
+	}
+	return keys.Keys, nil
+}
+
+// PutBatchStateE put [BatchKeys] to chain in one sys_call.
+// More than defaultLimitKeys keys is ErrLimitExceeded.
+//
+// PutBatchState and DeleteBatchState are sys_calls a host must provide besides the ones of
+// ChainMaker: the request body holds "BatchKeys", the bytes of vmPb.BatchKeys, and the host
+// writes, or deletes, every [key, field] of the calling contract, then returns 0, the
+// ContractName of the keys is ignored. A host without them returns a non-zero code, which is
+// *ErrHostCall naming the method, CachedSimContext.Flush then writes key by key
+func PutBatchStateE(batchKeys []*vmPb.BatchKey) error {
+	ec, err := batchKeysCodec(batchKeys)
+	if err != nil {
+		return err
+	}
+	return hostCall(ContractMethodPutBatchState, ec.Marshal())
+}
+
+// DeleteBatchStateE delete [BatchKeys] to chain in one sys_call, the values are ignored.
+// More than defaultLimitKeys keys is ErrLimitExceeded, see PutBatchStateE for the host contract
+func DeleteBatchStateE(batchKeys []*vmPb.BatchKey) error {
+	ec, err := batchKeysCodec(batchKeys)
+	if err != nil {
+		return err
+	}
+	return hostCall(ContractMethodDeleteBatchState, ec.Marshal())
+}
+
+func batchKeysCodec(batchKeys []*vmPb.BatchKey) (*EasyCodec, error) {
+	if err := batchKeysLimit(batchKeys); err != nil {
+		return nil, err
+	}
+	batchStateKeys := vmPb.BatchKeys{Keys: batchKeys}
+	batchStateKeysByte, err := batchStateKeys.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("marshal batch keys: %v: %w", err, ErrCodec)
+	}
+	ec := NewEasyCodec()
+	ec.AddBytes("BatchKeys", batchStateKeysByte)
+	return ec, nil
+}
+
+func batchKeysLimit(keys []*vmPb.BatchKey) error {
+	if len(keys) > defaultLimitKeys {
+		return fmt.Errorf("over batch keys count limit %d: %w", defaultLimitKeys, ErrLimitExceeded)
 	}
-	return SUCCESS
+	return nil
 }
 
 // CallContract call other contract from chain
 func CallContract(contractName string, method string, param map[string][]byte) ([]byte, ResultCode) {
+	result, err := CallContractE(contractName, method, param)
+	return result, resultCode(err)
+}
+
+// CallContractE call other contract from chain
+func CallContractE(contractName string, method string, param map[string][]byte) ([]byte, error) {
 	// # get len
 	// ## prepare param
 	var valueLen int32 = 0
-	valuePtr := int32(uintptr(unsafe.Pointer(&valueLen)))
+	valuePtr := int32Ptr(&valueLen)
 
 	ec := NewEasyCodec()
 	ecMap := NewEasyCodecWithMap(param)
@@ -662,47 +799,50 @@ func CallContract(contractName string, method string, param map[string][]byte) (
 	ec.AddInt32("value_ptr", valuePtr)
 	ec.AddString("contract_name", contractName)
 	ec.AddString("method", method)
-	b := ec.Marshal()
 	// ## send req get call len
-	code := sysCall(getRequestHeader(ContractMethodCallContractLen), string(b))
-	if code != int32(SUCCESS) {
-		return nil, ERROR
+	if err := hostCall(ContractMethodCallContractLen, ec.Marshal()); err != nil {
+		return nil, err
 	}
 	if valueLen == 0 {
-		return nil, SUCCESS
+		return nil, nil
 	}
 
 	// # get data
 	// ## prepare param
 	valueByte := make([]byte, valueLen)
-	valuePtr = int32(uintptr(unsafe.Pointer(&valueByte[0])))
-	ec.RemoveKey("value_ptr")
-	ec.AddInt32("value_ptr", valuePtr)
-	b = ec.Marshal()
+	valuePtr = ptrOf(valueByte)
+	ec.SetInt32("value_ptr", valuePtr)
 	// ## send req get value
-	code2 := sysCall(getRequestHeader(ContractMethodCallContract), string(b))
-	if code2 != int32(SUCCESS) {
-		return nil, ERROR
+	if err := hostCall(ContractMethodCallContract, ec.Marshal()); err != nil {
+		return nil, err
 	}
-	return valueByte, SUCCESS
+	return valueByte, nil
 }
 
 func DeleteStateFromKey(key string) ResultCode {
 	return DeleteState(key, "")
 }
 
-// SuccessResult record success data
+// SuccessResult record success data, inside a Contract method it is reported with the Response
 func SuccessResult(msg string) {
-	sysCall(getRequestHeader(ContractMethodSuccessResult), msg)
+	SuccessResultByte([]byte(msg))
 }
 
-// SuccessResult record success data
+// SuccessResult record success data, inside a Contract method it is reported with the Response
 func SuccessResultByte(msg []byte) {
+	if recorder != nil {
+		recorder.recordSuccess(msg)
+		return
+	}
 	sysCall(getRequestHeader(ContractMethodSuccessResult), string(msg))
 }
 
-// ErrorResult record error msg
+// ErrorResult record error msg, inside a Contract method it is reported with the Response
 func ErrorResult(msg string) {
+	if recorder != nil {
+		recorder.recordError(msg)
+		return
+	}
 	sysCall(getRequestHeader(ContractMethodErrorResult), string(msg))
 }
 
@@ -755,30 +895,31 @@ func stringArg(key string) (string, ResultCode) {
 	return string(result), code
 }
 func Arg(key string) ([]byte, ResultCode) {
+	value, err := ArgE(key)
+	return value, resultCode(err)
+}
+
+// ArgE get arg from transaction parameters, a missing arg is ErrNotFound
+func ArgE(key string) ([]byte, error) {
 	err := getArgsMap()
 	if err != nil {
 		LogMessage("get Arg error:" + err.Error())
-		return nil, ERROR
+		return nil, err
 	}
 	for _, v := range argsMap {
 		if v.Key == key {
-			return v.Value.([]byte), SUCCESS
+			value, ok := v.Value.([]byte)
+			if !ok {
+				return nil, fmt.Errorf("arg %s is not bytes: %w", key, ErrCodec)
+			}
+			return value, nil
 		}
 	}
-	return nil, ERROR
+	return nil, fmt.Errorf("arg %s: %w", key, ErrNotFound)
 }
 func ArgString(key string) (string, ResultCode) {
-	err := getArgsMap()
-	if err != nil {
-		LogMessage("get Arg error:" + err.Error())
-		return "", ERROR
-	}
-	for _, v := range argsMap {
-		if v.Key == key {
-			return string(v.Value.([]byte)), SUCCESS
-		}
-	}
-	return "", ERROR
+	value, err := ArgE(key)
+	return string(value), resultCode(err)
 }
 
 func Args() []*EasyCodecItem {
@@ -797,7 +938,7 @@ func (s *SimContextImpl) newIterator(startKey string, startField string, limitKe
 	ec.AddString("limit_key", limitKey)
 	ec.AddString("limit_field", limitField)
 	index, code := GetInt32FromChain(ec, ContractMethodKvIterator)
-	return &ResultSetKvImpl{index}, code
+	return &ResultSetKvImpl{index: index}, code
 }
 
 func (s *SimContextImpl) NewIteratorWithField(key string, startField string, limitField string) (ResultSetKV, ResultCode) {
@@ -809,12 +950,20 @@ func (s *SimContextImpl) NewIterator(key string, limit string) (ResultSetKV, Res
 	return s.newIterator(key, "", limit, "")
 }
 
+// NewIteratorWithOptions see IteratorOptionsContext, ERROR when NewIteratorWithOptionsE fails, as
+// with Descending on a host that does not support the options
+func (s *SimContextImpl) NewIteratorWithOptions(startKey string, startField string, limitKey string, limitField string,
+	opts IteratorOptions) (ResultSetKV, ResultCode) {
+	rs, err := NewIteratorWithOptionsE(startKey, startField, limitKey, limitField, opts)
+	return rs, resultCode(err)
+}
+
 func (s *SimContextImpl) NewIteratorPrefixWithKeyField(startKey string, startField string) (ResultSetKV, ResultCode) {
 	ec := NewEasyCodec()
 	ec.AddString("start_key", startKey)
 	ec.AddString("start_field", startField)
 	index, code := GetInt32FromChain(ec, ContractMethodKvPreIterator)
-	return &ResultSetKvImpl{index}, code
+	return &ResultSetKvImpl{index: index}, code
 }
 func (s *SimContextImpl) NewHistoryKvIterForKey(startKey string, startField string) (KeyHistoryKvIter, ResultCode) {
 	ec := NewEasyCodec()
@@ -834,6 +983,7 @@ func (s *SimContextImpl) NewIteratorPrefixWithKey(key string) (ResultSetKV, Resu
 // ResultSet iterator query result KVdb
 type ResultSetKvImpl struct { //为kv查询后的上下文
 	index int32 // 链的句柄的index
+	err   error // the last failing sys_call, see hostError
 }
 
 func (r *ResultSetKvImpl) HasNext() bool {
@@ -846,19 +996,26 @@ func (r *ResultSetKvImpl) HasNext() bool {
 func (r *ResultSetKvImpl) NextRow() (*EasyCodec, ResultCode) {
 	ec := NewEasyCodec()
 	ec.AddInt32("rs_index", r.index)
-	bytes, code := GetBytesFromChain(ec, ContractMethodKvIteratorNextLen, ContractMethodKvIteratorNext)
-	if code != SUCCESS {
+	bytes, err := GetBytesFromChainE(ec, ContractMethodKvIteratorNextLen, ContractMethodKvIteratorNext)
+	if err != nil {
+		r.err = err
 		return nil, ERROR
 	}
-	ec = NewEasyCodecWithBytes(bytes)
-	return ec, code
+	return NewEasyCodecWithBytes(bytes), SUCCESS
 }
 
 func (r *ResultSetKvImpl) Close() (bool, ResultCode) {
 	ec := NewEasyCodec()
 	ec.AddInt32("rs_index", r.index)
-	data, code := GetInt32FromChain(ec, ContractMethodKvIteratorClose)
-	return data != 0, code
+	data, err := GetInt32FromChainE(ec, ContractMethodKvIteratorClose)
+	if err != nil {
+		r.err = err
+	}
+	return data != 0, resultCode(err)
+}
+
+func (r *ResultSetKvImpl) hostError() error {
+	return r.err
 }
 
 func (r *ResultSetKvImpl) Next() (string, string, []byte, ResultCode) {
@@ -866,16 +1023,25 @@ func (r *ResultSetKvImpl) Next() (string, string, []byte, ResultCode) {
 	if code != SUCCESS {
 		return "", "", nil, ERROR
 	}
+	return kvOfRow(ec)
+}
+
+// kvOfRow split a row of a kv iterator, a missing value is empty
+func kvOfRow(ec *EasyCodec) (string, string, []byte, ResultCode) {
 	k, _ := ec.GetString("key")
 	field, _ := ec.GetString("field")
 	v, _ := ec.GetBytes("value")
-	return k, field, v, code
+	if v == nil {
+		v = []byte{}
+	}
+	return k, field, v, SUCCESS
 }
 
 type KeyHistoryKvIterImpl struct {
 	key   string
 	field string
 	index int32
+	err   error // the last failing sys_call, see hostError
 }
 
 func (k *KeyHistoryKvIterImpl) HasNext() bool {
@@ -888,19 +1054,26 @@ func (k *KeyHistoryKvIterImpl) HasNext() bool {
 func (k *KeyHistoryKvIterImpl) NextRow() (*EasyCodec, ResultCode) {
 	ec := NewEasyCodec()
 	ec.AddInt32("ks_index", k.index)
-	bytes, code := GetBytesFromChain(ec, ContractHistoryKvIteratorNextLen, ContractHistoryKvIteratorNext)
-	if code != SUCCESS {
+	bytes, err := GetBytesFromChainE(ec, ContractHistoryKvIteratorNextLen, ContractHistoryKvIteratorNext)
+	if err != nil {
+		k.err = err
 		return nil, ERROR
 	}
-	ec = NewEasyCodecWithBytes(bytes)
-	return ec, code
+	return NewEasyCodecWithBytes(bytes), SUCCESS
 }
 
 func (k *KeyHistoryKvIterImpl) Close() (bool, ResultCode) {
 	ec := NewEasyCodec()
 	ec.AddInt32("ks_index", k.index)
-	data, code := GetInt32FromChain(ec, ContractHistoryKvIteratorClose)
-	return data != 0, code
+	data, err := GetInt32FromChainE(ec, ContractHistoryKvIteratorClose)
+	if err != nil {
+		k.err = err
+	}
+	return data != 0, resultCode(err)
+}
+
+func (k *KeyHistoryKvIterImpl) hostError() error {
+	return k.err
 }
 
 func (k *KeyHistoryKvIterImpl) Next() (*KeyModification, ResultCode) {
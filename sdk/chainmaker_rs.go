@@ -7,7 +7,8 @@ SPDX-License-Identifier: Apache-2.0
 
 package sdk
 
-// ResultSet iterator query result
+// ResultSet iterator query result, ForEachRow, ForEachKV and ForEachHistory or the go1.23 All* range
+// functions iterate and always close it
 type ResultSet interface {
 	// NextRow get next row,
 	// sql: column name is EasyCodec key, value is EasyCodec string val. as: val := ec.getString("columnName")
@@ -21,7 +22,7 @@ type ResultSet interface {
 
 type ResultSetKV interface {
 	ResultSet
-	// Next return key,field,value,code
+	// Next return key,field,value,code, every row exists so value is never nil
 	Next() (string, string, []byte, ResultCode)
 }
 type KeyHistoryKvIter interface {
@@ -118,9 +119,14 @@ func (s *SqlSimContextImpl) ExecuteDdl(sql string) (int32, ResultCode) {
 }
 
 func (s *SqlSimContextImpl) IteratorNextRow(rsIndex int32) ([]byte, ResultCode) {
+	row, err := iteratorNextRowE(rsIndex)
+	return row, resultCode(err)
+}
+
+func iteratorNextRowE(rsIndex int32) ([]byte, error) {
 	ec := NewEasyCodec()
 	ec.AddInt32("rs_index", rsIndex)
-	return GetBytesFromChain(ec, ContractMethodRSNextLen, ContractMethodRSNext)
+	return GetBytesFromChainE(ec, ContractMethodRSNextLen, ContractMethodRSNext)
 }
 
 func (s *SqlSimContextImpl) IteratorHasNext(rsIndex int32) (int32, ResultCode) {
@@ -129,23 +135,30 @@ func (s *SqlSimContextImpl) IteratorHasNext(rsIndex int32) (int32, ResultCode) {
 	return GetInt32FromChain(ec, ContractMethodRSHasNext)
 }
 func (s *SqlSimContextImpl) IteratorClose(rsIndex int32) (int32, ResultCode) {
+	data, err := iteratorCloseE(rsIndex)
+	return data, resultCode(err)
+}
+
+func iteratorCloseE(rsIndex int32) (int32, error) {
 	ec := NewEasyCodec()
 	ec.AddInt32("rs_index", rsIndex)
-	return GetInt32FromChain(ec, ContractMethodRSClose)
+	return GetInt32FromChainE(ec, ContractMethodRSClose)
 }
 
 type ResultSetImpl struct {
 	sqlCtx *SqlSimContextImpl
 	index  int32 // 链的rs句柄的index
+	err    error // the last failing sys_call, see hostError
 }
 
 func NewResultSet(sqlCtx *SqlSimContextImpl, index int32) ResultSet {
-	return &ResultSetImpl{sqlCtx, index}
+	return &ResultSetImpl{sqlCtx: sqlCtx, index: index}
 }
 
 func (r *ResultSetImpl) NextRow() (*EasyCodec, ResultCode) {
-	bytes, code := r.sqlCtx.IteratorNextRow(r.index)
-	if code != SUCCESS {
+	bytes, err := iteratorNextRowE(r.index)
+	if err != nil {
+		r.err = err
 		return NewEasyCodec(), ERROR
 	}
 	return NewEasyCodecWithBytes(bytes), SUCCESS
@@ -157,6 +170,13 @@ func (r *ResultSetImpl) HasNext() bool {
 }
 
 func (r *ResultSetImpl) Close() (bool, ResultCode) {
-	data, code := r.sqlCtx.IteratorClose(r.index)
-	return data != 0, code
+	data, err := iteratorCloseE(r.index)
+	if err != nil {
+		r.err = err
+	}
+	return data != 0, resultCode(err)
+}
+
+func (r *ResultSetImpl) hostError() error {
+	return r.err
 }
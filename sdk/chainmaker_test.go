@@ -0,0 +1,304 @@
+//go:build !wasm
+// +build !wasm
+
+/*
+Copyright (C) BABEC. All rights reserved.
+Copyright (C) THL A29 Limited, a Tencent company. All rights reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package sdk
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	vmPb "chainmaker.org/chainmaker/pb-go/v2/vm"
+)
+
+// recordedCall one request received by fakeHost
+type recordedCall struct {
+	header *EasyCodec
+	body   *EasyCodec
+}
+
+// fakeHost answer the len sys_calls with value and the data sys_calls by copying it,
+// the methods of fail are answered with their code, and every method with code when set
+type fakeHost struct {
+	calls []recordedCall
+	value []byte
+	code  int32
+	fail  map[string]int32
+}
+
+func (h *fakeHost) SysCall(requestHeader string, requestBody string) int32 {
+	header, err := EasyUnmarshalStrict([]byte(requestHeader))
+	if err != nil {
+		panic(err)
+	}
+	body, err := EasyUnmarshalStrict([]byte(requestBody))
+	if err != nil {
+		panic(err)
+	}
+	call := recordedCall{NewEasyCodecWithItems(header), NewEasyCodecWithItems(body)}
+	h.calls = append(h.calls, call)
+	if h.code != 0 {
+		return h.code
+	}
+	if method, _ := call.header.GetValue("method", EasyKeyType_SYSTEM); h.fail[method.(string)] != 0 {
+		return h.fail[method.(string)]
+	}
+	ptr, err := call.body.GetInt32("value_ptr")
+	if err != nil {
+		return 0
+	}
+	mem := HostMemory(ptr)
+	if len(mem) == 4 {
+		binary.LittleEndian.PutUint32(mem, uint32(len(h.value)))
+	} else {
+		copy(mem, h.value)
+	}
+	return 0
+}
+
+func (h *fakeHost) Log(msg string) {}
+
+func (h *fakeHost) LogWithType(msg string, msgType int32) {}
+
+func withFakeHost(t *testing.T, h *fakeHost) {
+	args := NewEasyCodec()
+	args.AddBytes(ContractParamContextPtr, []byte("42"))
+	SetHost(h)
+	SetArgs(args.Marshal())
+	t.Cleanup(func() {
+		SetHost(nil)
+		SetArgs(nil)
+	})
+}
+
+func (c recordedCall) method(t *testing.T) string {
+	t.Helper()
+	ctxPtr, err := c.header.GetValue("ctx_ptr", EasyKeyType_SYSTEM)
+	if err != nil || ctxPtr != int32(42) {
+		t.Fatalf("ctx_ptr %v, %v", ctxPtr, err)
+	}
+	version, err := c.header.GetValue("version", EasyKeyType_SYSTEM)
+	if err != nil || version != "v1.2.0" {
+		t.Fatalf("version %v, %v", version, err)
+	}
+	method, err := c.header.GetValue("method", EasyKeyType_SYSTEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return method.(string)
+}
+
+func TestGetStateFraming(t *testing.T) {
+	h := &fakeHost{value: []byte("100")}
+	withFakeHost(t, h)
+
+	value, err := GetStateByteE("balance", "alice")
+	if err != nil || !bytes.Equal(value, h.value) {
+		t.Fatalf("got %q, %v", value, err)
+	}
+	if len(h.calls) != 2 {
+		t.Fatalf("%d sys_calls, want the len call then the data call", len(h.calls))
+	}
+	for i, want := range []string{ContractMethodGetStateLen, ContractMethodGetState} {
+		call := h.calls[i]
+		if method := call.method(t); method != want {
+			t.Fatalf("call %d method %s, want %s", i, method, want)
+		}
+		key, _ := call.body.GetString("key")
+		field, _ := call.body.GetString("field")
+		if key != "balance" || field != "alice" {
+			t.Fatalf("call %d key %q field %q", i, key, field)
+		}
+		if n := len(call.body.GetAll("value_ptr")); n != 1 {
+			t.Fatalf("call %d carries %d value_ptr", i, n)
+		}
+	}
+}
+
+func TestGetStateEmptySkipsDataCall(t *testing.T) {
+	h := &fakeHost{}
+	withFakeHost(t, h)
+
+	value, err := GetStateByteE("balance", "nobody")
+	if err != nil || value != nil {
+		t.Fatalf("got %q, %v", value, err)
+	}
+	if len(h.calls) != 1 {
+		t.Fatalf("%d sys_calls, want only the len call", len(h.calls))
+	}
+}
+
+func TestPutStateFraming(t *testing.T) {
+	h := &fakeHost{}
+	withFakeHost(t, h)
+
+	if err := PutStateByteE("balance", "alice", []byte{0, 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := DeleteStateE("balance", "bob"); err != nil {
+		t.Fatal(err)
+	}
+	if method := h.calls[0].method(t); method != ContractMethodPutState {
+		t.Fatalf("method %s", method)
+	}
+	value, _ := h.calls[0].body.GetBytes("value")
+	if !bytes.Equal(value, []byte{0, 1}) {
+		t.Fatalf("value %v", value)
+	}
+	if method := h.calls[1].method(t); method != ContractMethodDeleteState {
+		t.Fatalf("method %s", method)
+	}
+	if field, _ := h.calls[1].body.GetString("field"); field != "bob" {
+		t.Fatalf("field %q", field)
+	}
+}
+
+// copyingHost fakeHost that also keeps a copy of each raw request body
+type copyingHost struct {
+	fakeHost
+	bodies [][]byte
+}
+
+func (h *copyingHost) SysCall(requestHeader string, requestBody string) int32 {
+	h.bodies = append(h.bodies, []byte(requestBody))
+	return h.fakeHost.SysCall(requestHeader, requestBody)
+}
+
+func TestConsecutiveBodiesDoNotShare(t *testing.T) {
+	h := &copyingHost{}
+	withFakeHost(t, &h.fakeHost)
+	SetHost(h)
+
+	long := bytes.Repeat([]byte{7}, 1000)
+	if err := PutStateByteE("balance", "alice", long); err != nil {
+		t.Fatal(err)
+	}
+	if err := EmitEventE("topic", "a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := PutStateByteE("k", "f", []byte{1}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []*EasyCodec{NewEasyCodec(), NewEasyCodec(), NewEasyCodec()}
+	want[0].AddString("key", "balance")
+	want[0].AddString("field", "alice")
+	want[0].AddBytes("value", long)
+	want[1].AddString("topic", "topic")
+	want[1].AddString("data0", "a")
+	want[2].AddString("key", "k")
+	want[2].AddString("field", "f")
+	want[2].AddBytes("value", []byte{1})
+	for i, w := range want {
+		if !bytes.Equal(h.bodies[i], w.Marshal()) {
+			t.Errorf("body %d was not sent whole on its own", i)
+		}
+	}
+	// the recorded copies are unchanged by the calls that reused the buffer
+	if value, _ := h.calls[0].body.GetBytes("value"); !bytes.Equal(value, long) {
+		t.Errorf("first body changed after the next calls")
+	}
+	if topic, _ := h.calls[1].body.GetString("topic"); topic != "topic" || len(h.calls[1].body.GetItems()) != 2 {
+		t.Errorf("event body %v", h.calls[1].body.GetItems())
+	}
+}
+
+func TestHostCallError(t *testing.T) {
+	h := &fakeHost{code: 1}
+	withFakeHost(t, h)
+
+	err := PutStateE("balance", "alice", "1")
+	var hostErr *ErrHostCall
+	if !errors.As(err, &hostErr) || hostErr.Method != ContractMethodPutState || hostErr.Code != 1 {
+		t.Fatalf("got %v", err)
+	}
+	if code := PutState("balance", "alice", "1"); code != ERROR {
+		t.Fatalf("legacy code %d", code)
+	}
+}
+
+func TestRequestHeaderFollowsArgs(t *testing.T) {
+	h := &fakeHost{}
+	withFakeHost(t, h)
+
+	first := getRequestHeader(ContractMethodPutState)
+	if getRequestHeader(ContractMethodPutState) != first {
+		t.Fatal("header of the same method changed")
+	}
+	args := NewEasyCodec()
+	args.AddBytes(ContractParamContextPtr, []byte("43"))
+	SetArgs(args.Marshal())
+	if getRequestHeader(ContractMethodPutState) == first {
+		t.Fatal("header kept the ctx_ptr of the previous args")
+	}
+}
+
+func TestBatchStateOnHostWithoutIt(t *testing.T) {
+	h := &fakeHost{fail: map[string]int32{ContractMethodPutBatchState: 1, ContractMethodDeleteBatchState: 1}}
+	withFakeHost(t, h)
+
+	ctx := NewCachedSimContext()
+	ctx.PutBatchState([]*vmPb.BatchKey{{Key: "balance", Field: "alice", Value: []byte("1")}})
+	ctx.DeleteState("balance", "bob")
+	if err := ctx.FlushE(); err != nil {
+		t.Fatalf("flush on a host without batch sys_calls: %v", err)
+	}
+	got := methods(t, h.calls)
+	if len(got) != 3 || got[0] != ContractMethodPutBatchState || got[1] != ContractMethodPutState ||
+		got[2] != ContractMethodDeleteState {
+		t.Fatalf("sys_calls %v, want one failed batch then key by key writes", got)
+	}
+	if value, _ := h.calls[1].body.GetBytes("value"); string(value) != "1" {
+		t.Fatalf("value %q", value)
+	}
+	h.calls = nil
+	ctx.PutState("balance", "carol", "2")
+	ctx.FlushE()
+	if got = methods(t, h.calls); len(got) != 1 || got[0] != ContractMethodPutState {
+		t.Fatalf("sys_calls %v, want no batch retried", got)
+	}
+
+	batchCtx := NewSimContext().(BatchStateContext)
+	if code := batchCtx.DeleteBatchState([]*vmPb.BatchKey{{Key: "balance"}}); code != ERROR {
+		t.Fatalf("delete batch returned %d", code)
+	}
+}
+
+func TestFlushReturnsWriteError(t *testing.T) {
+	h := &fakeHost{code: 1}
+	withFakeHost(t, h)
+
+	ctx := NewCachedSimContext()
+	ctx.PutState("balance", "alice", "1")
+	err := ctx.FlushE()
+	var hostErr *ErrHostCall
+	if !errors.As(err, &hostErr) || hostErr.Method != ContractMethodPutState {
+		t.Fatalf("got %v, want the error of the key by key write", err)
+	}
+}
+
+func TestTypedStateReturnsHostError(t *testing.T) {
+	h := &fakeHost{code: 3}
+	withFakeHost(t, h)
+
+	for _, ctx := range []SimContext{NewSimContext(), NewCachedSimContext()} {
+		_, _, err := GetStateInt64(ctx, "n", "i")
+		var hostErr *ErrHostCall
+		if !errors.As(err, &hostErr) || hostErr.Method != ContractMethodGetStateLen || hostErr.Code != 3 {
+			t.Fatalf("%T: got %v", ctx, err)
+		}
+	}
+	err := PutStateInt64(NewSimContext(), "n", "i", 1)
+	var hostErr *ErrHostCall
+	if !errors.As(err, &hostErr) || hostErr.Method != ContractMethodPutState || hostErr.Code != 3 {
+		t.Fatalf("put: got %v", err)
+	}
+}
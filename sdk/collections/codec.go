@@ -0,0 +1,164 @@
+/*
+Copyright (C) BABEC. All rights reserved.
+Copyright (C) THL A29 Limited, a Tencent company. All rights reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package collections
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/TKOTKCh/contract-sdk-go-wasm/sdk"
+)
+
+// KeyCodec encode the keys of a collection into state fields. Distinct keys must have distinct
+// fields, iteration follows the order of the fields. A key encoded as the empty field is rejected
+type KeyCodec[K any] interface {
+	EncodeKey(key K) (string, error)
+	DecodeKey(field string) (K, error)
+}
+
+// ValueCodec encode the values of a collection into state values
+type ValueCodec[V any] interface {
+	Encode(value V) ([]byte, error)
+	Decode(data []byte) (V, error)
+}
+
+var (
+	// StringKey use the string itself as field
+	StringKey KeyCodec[string] = stringKey{}
+	// Int64Key encode as 20 digits keeping the numeric order, negative numbers first
+	Int64Key KeyCodec[int64] = int64Key{}
+	// Uint64Key encode as 20 digits keeping the numeric order
+	Uint64Key KeyCodec[uint64] = uint64Key{}
+
+	// StringValue store the string bytes
+	StringValue ValueCodec[string] = stringValue{}
+	// BytesValue store the bytes as is
+	BytesValue ValueCodec[[]byte] = bytesValue{}
+	// Int64Value store a base 10 string, as sdk.PutStateInt64
+	Int64Value ValueCodec[int64] = int64Value{}
+	// Uint64Value store a base 10 string, as sdk.PutStateUint64
+	Uint64Value ValueCodec[uint64] = uint64Value{}
+	// BoolValue store "true" or "false", as sdk.PutStateBool
+	BoolValue ValueCodec[bool] = boolValue{}
+)
+
+// ProtoValue store protobuf messages, newMsg create the message to decode into
+func ProtoValue[T sdk.ProtoMessage](newMsg func() T) ValueCodec[T] {
+	return protoValue[T]{newMsg: newMsg}
+}
+
+func codecError(typ string, data string, err error) error {
+	return fmt.Errorf("decode %s %q: %v: %w", typ, data, err, sdk.ErrCodec)
+}
+
+type stringKey struct{}
+
+func (stringKey) EncodeKey(key string) (string, error)   { return key, nil }
+func (stringKey) DecodeKey(field string) (string, error) { return field, nil }
+
+type int64Key struct{}
+
+func (int64Key) EncodeKey(key int64) (string, error) {
+	return fmt.Sprintf("%020d", uint64(key)^(1<<63)), nil
+}
+
+func (int64Key) DecodeKey(field string) (int64, error) {
+	n, err := strconv.ParseUint(field, 10, 64)
+	if err != nil {
+		return 0, codecError("int64 key", field, err)
+	}
+	return int64(n ^ (1 << 63)), nil
+}
+
+type uint64Key struct{}
+
+func (uint64Key) EncodeKey(key uint64) (string, error) {
+	return fmt.Sprintf("%020d", key), nil
+}
+
+func (uint64Key) DecodeKey(field string) (uint64, error) {
+	n, err := strconv.ParseUint(field, 10, 64)
+	if err != nil {
+		return 0, codecError("uint64 key", field, err)
+	}
+	return n, nil
+}
+
+type stringValue struct{}
+
+func (stringValue) Encode(value string) ([]byte, error) { return []byte(value), nil }
+func (stringValue) Decode(data []byte) (string, error)  { return string(data), nil }
+
+type bytesValue struct{}
+
+func (bytesValue) Encode(value []byte) ([]byte, error) { return value, nil }
+func (bytesValue) Decode(data []byte) ([]byte, error)  { return data, nil }
+
+type int64Value struct{}
+
+func (int64Value) Encode(value int64) ([]byte, error) {
+	return []byte(strconv.FormatInt(value, 10)), nil
+}
+
+func (int64Value) Decode(data []byte) (int64, error) {
+	n, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		return 0, codecError("int64", string(data), err)
+	}
+	return n, nil
+}
+
+type uint64Value struct{}
+
+func (uint64Value) Encode(value uint64) ([]byte, error) {
+	return []byte(strconv.FormatUint(value, 10)), nil
+}
+
+func (uint64Value) Decode(data []byte) (uint64, error) {
+	n, err := strconv.ParseUint(string(data), 10, 64)
+	if err != nil {
+		return 0, codecError("uint64", string(data), err)
+	}
+	return n, nil
+}
+
+type boolValue struct{}
+
+func (boolValue) Encode(value bool) ([]byte, error) {
+	return []byte(strconv.FormatBool(value)), nil
+}
+
+func (boolValue) Decode(data []byte) (bool, error) {
+	switch string(data) {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	return false, codecError("bool", string(data), fmt.Errorf("invalid bool"))
+}
+
+type protoValue[T sdk.ProtoMessage] struct {
+	newMsg func() T
+}
+
+func (p protoValue[T]) Encode(value T) ([]byte, error) {
+	data, err := value.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("encode proto message: %v: %w", err, sdk.ErrCodec)
+	}
+	return data, nil
+}
+
+func (p protoValue[T]) Decode(data []byte) (T, error) {
+	msg := p.newMsg()
+	if err := msg.Unmarshal(data); err != nil {
+		return msg, fmt.Errorf("decode proto message: %v: %w", err, sdk.ErrCodec)
+	}
+	return msg, nil
+}
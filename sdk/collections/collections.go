@@ -0,0 +1,88 @@
+/*
+Copyright (C) BABEC. All rights reserved.
+Copyright (C) THL A29 Limited, a Tencent company. All rights reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package collections provides persistent collections on top of sdk.SimContext. Each collection
+// lives under its own prefix, used as the state key, with the following layout:
+//
+//	Map[K, V]  field: encoded K                value: encoded V
+//	Set[K]     field: encoded K                value: "1"
+//	List[V]    field: "len"                    value: length, base 10
+//	           field: index, 20 digits         value: encoded V
+//	Queue[V]   field: "head", "tail"           value: positions, base 10
+//	           field: position, 20 digits      value: encoded V
+//
+// IndexedMap[K, V] stores its records as Map[K, V] and its index entries under the key
+// sdk.CreateCompositeKey(prefix, "index"):
+//
+//	unique     field: composite(name, value)            value: encoded K
+//	non unique field: composite(name, value, encoded K) value: encoded K
+//
+// Prefixes must not be shared between collections.
+package collections
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/TKOTKCh/contract-sdk-go-wasm/sdk"
+)
+
+// encodeKey encode key as a field. The empty field is the state key prefix itself, which the
+// iteration of the collection skips, so an empty encoded key is sdk.ErrCodec
+func encodeKey[K any](keyCodec KeyCodec[K], key K) (string, error) {
+	field, err := keyCodec.EncodeKey(key)
+	if err != nil {
+		return "", err
+	}
+	if field == "" {
+		return "", fmt.Errorf("key %v encoded as an empty field: %w", key, sdk.ErrCodec)
+	}
+	return field, nil
+}
+
+func getCounter(ctx sdk.StateContextE, prefix string, field string) (uint64, error) {
+	value, exists, err := ctx.GetStateWithExistsE(prefix, field)
+	if err != nil || !exists {
+		return 0, err
+	}
+	n, err := strconv.ParseUint(string(value), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("counter %s#%s: %v: %w", prefix, field, err, sdk.ErrCodec)
+	}
+	return n, nil
+}
+
+func putCounter(ctx sdk.StateContextE, prefix string, field string, n uint64) error {
+	return ctx.PutStateByteE(prefix, field, []byte(strconv.FormatUint(n, 10)))
+}
+
+// positionField encode a list index or queue position, fixed width keeps the iteration order
+func positionField(i uint64) string {
+	return fmt.Sprintf("%020d", i)
+}
+
+// iterate call fn for every field stored under prefix, in field order
+func iterate(ctx sdk.SimContext, prefix string, fn func(field string, value []byte) (bool, error)) error {
+	rs, code := ctx.NewIteratorPrefixWithKey(prefix)
+	if code != sdk.SUCCESS {
+		return &sdk.ErrHostCall{Method: sdk.ContractMethodKvPreIterator, Code: int32(code)}
+	}
+	var err error
+	iterErr := sdk.ForEachKV(rs, func(key string, field string, value []byte) bool {
+		// the prefix iterator also matches longer keys starting with prefix
+		if key != prefix || field == "" {
+			return true
+		}
+		var next bool
+		next, err = fn(field, value)
+		return err == nil && next
+	})
+	if err != nil {
+		return err
+	}
+	return iterErr
+}
@@ -0,0 +1,169 @@
+//go:build !wasm
+// +build !wasm
+
+/*
+Copyright (C) BABEC. All rights reserved.
+Copyright (C) THL A29 Limited, a Tencent company. All rights reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package collections
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/TKOTKCh/contract-sdk-go-wasm/sdk"
+	"github.com/TKOTKCh/contract-sdk-go-wasm/sdk/mock"
+)
+
+// invoke run fn in a transaction of a new mock chain and fail on a contract error
+func invoke(t *testing.T, fn func(ctx sdk.SimContext)) *mock.Result {
+	t.Helper()
+	result := mock.NewChain().Invoke(func() { fn(sdk.NewSimContext()) }, nil)
+	if result.IsError {
+		t.Fatalf("contract error %s", result.Message)
+	}
+	return result
+}
+
+func TestMapInt64KeyOrder(t *testing.T) {
+	invoke(t, func(ctx sdk.SimContext) {
+		m := NewMap(ctx, "m", Int64Key, StringValue)
+		keys := []int64{5, math.MinInt64, -1, 0, math.MaxInt64, -300, 42}
+		for _, k := range keys {
+			if err := m.Set(k, "v"); err != nil {
+				t.Fatal(err)
+			}
+		}
+		var got []int64
+		if err := m.Iterate(func(k int64, v string) bool {
+			got = append(got, k)
+			return true
+		}); err != nil {
+			t.Fatal(err)
+		}
+		want := []int64{math.MinInt64, -300, -1, 0, 5, 42, math.MaxInt64}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	})
+}
+
+func TestMapGetSetDelete(t *testing.T) {
+	invoke(t, func(ctx sdk.SimContext) {
+		m := NewMap(ctx, "m", StringKey, Uint64Value)
+		if _, ok, err := m.Get("a"); ok || err != nil {
+			t.Fatalf("missing key: %v, %v", ok, err)
+		}
+		m.Set("a", 0)
+		if v, ok, err := m.Get("a"); !ok || err != nil || v != 0 {
+			t.Fatalf("zero value: %d, %v, %v", v, ok, err)
+		}
+		m.Delete("a")
+		if ok, _ := m.Has("a"); ok {
+			t.Fatal("deleted key exists")
+		}
+	})
+}
+
+func TestEmptyKeyRejected(t *testing.T) {
+	invoke(t, func(ctx sdk.SimContext) {
+		m := NewMap(ctx, "m", StringKey, StringValue)
+		if err := m.Set("", "v"); !errors.Is(err, sdk.ErrCodec) {
+			t.Fatalf("map set: got %v, want ErrCodec", err)
+		}
+		if _, _, err := m.Get(""); !errors.Is(err, sdk.ErrCodec) {
+			t.Fatalf("map get: got %v, want ErrCodec", err)
+		}
+		s := NewSet(ctx, "s", StringKey)
+		if err := s.Add(""); !errors.Is(err, sdk.ErrCodec) {
+			t.Fatalf("set add: got %v, want ErrCodec", err)
+		}
+	})
+}
+
+func TestListBounds(t *testing.T) {
+	invoke(t, func(ctx sdk.SimContext) {
+		l := NewList(ctx, "l", StringValue)
+		if _, err := l.Get(0); !errors.Is(err, sdk.ErrNotFound) {
+			t.Fatalf("get on empty list: got %v", err)
+		}
+		for i, v := range []string{"a", "b"} {
+			if index, err := l.Append(v); err != nil || index != uint64(i) {
+				t.Fatalf("append %d: %d, %v", i, index, err)
+			}
+		}
+		if err := l.Set(2, "c"); !errors.Is(err, sdk.ErrNotFound) {
+			t.Fatalf("set past the end: got %v", err)
+		}
+		if _, err := l.Get(2); !errors.Is(err, sdk.ErrNotFound) {
+			t.Fatalf("get past the end: got %v", err)
+		}
+		if err := l.Set(1, "B"); err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		l.Iterate(func(index uint64, v string) bool {
+			got = append(got, v)
+			return true
+		})
+		if n, _ := l.Len(); n != 2 || !reflect.DeepEqual(got, []string{"a", "B"}) {
+			t.Fatalf("len %d, values %v", n, got)
+		}
+	})
+}
+
+func TestQueueFIFOAfterDrain(t *testing.T) {
+	invoke(t, func(ctx sdk.SimContext) {
+		q := NewQueue(ctx, "q", Int64Value)
+		pop := func() int64 {
+			t.Helper()
+			v, ok, err := q.Pop()
+			if !ok || err != nil {
+				t.Fatalf("pop: %v, %v", ok, err)
+			}
+			return v
+		}
+		for round := 0; round < 3; round++ {
+			for i := int64(0); i < 3; i++ {
+				q.Push(int64(round)*10 + i)
+			}
+			if v, ok, _ := q.Peek(); !ok || v != int64(round)*10 {
+				t.Fatalf("round %d peek %d, %v", round, v, ok)
+			}
+			for i := int64(0); i < 3; i++ {
+				if v := pop(); v != int64(round)*10+i {
+					t.Fatalf("round %d pop %d, want %d", round, v, int64(round)*10+i)
+				}
+			}
+			if _, ok, err := q.Pop(); ok || err != nil {
+				t.Fatalf("round %d pop on empty queue: %v, %v", round, ok, err)
+			}
+		}
+		if n, _ := q.Len(); n != 0 {
+			t.Fatalf("len %d", n)
+		}
+	})
+}
+
+func TestCollectionsDoNotShareState(t *testing.T) {
+	result := invoke(t, func(ctx sdk.SimContext) {
+		NewMap(ctx, "m", StringKey, StringValue).Set("a", "1")
+		NewMap(ctx, "mx", StringKey, StringValue).Set("b", "2")
+		n := 0
+		NewMap(ctx, "m", StringKey, StringValue).Iterate(func(k string, v string) bool {
+			n++
+			return true
+		})
+		if n != 1 {
+			t.Fatalf("map m iterates %d entries, want 1", n)
+		}
+	})
+	if len(result.WriteSet) != 2 {
+		t.Fatalf("write set %+v", result.WriteSet)
+	}
+}
@@ -0,0 +1,214 @@
+/*
+Copyright (C) BABEC. All rights reserved.
+Copyright (C) THL A29 Limited, a Tencent company. All rights reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package collections
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/TKOTKCh/contract-sdk-go-wasm/sdk"
+)
+
+// Index secondary index of an IndexedMap
+type Index[V any] struct {
+	// Name of the index, unique among the indexes of a map
+	Name string
+	// Unique reject a record whose value is already indexed for another key
+	Unique bool
+	// Values return the indexed values of a record, as parts of sdk.CreateCompositeKey,
+	// none when the record is out of the index
+	Values func(value V) []interface{}
+}
+
+// IndexedMap Map whose Set and Delete maintain secondary indexes, so that records can be looked
+// up by their indexed values. Records must only be written through the IndexedMap
+type IndexedMap[K any, V any] struct {
+	records  *Map[K, V]
+	indexKey string
+	indexes  []Index[V]
+}
+
+// NewIndexedMap create an IndexedMap stored under prefix with indexes. An index without a name or
+// Values is sdk.ErrInvalidArgument, two indexes of the same name sdk.ErrDuplicateKey
+func NewIndexedMap[K any, V any](ctx sdk.SimContext, prefix string, keyCodec KeyCodec[K], valueCodec ValueCodec[V],
+	indexes ...Index[V]) (*IndexedMap[K, V], error) {
+	names := make(map[string]bool, len(indexes))
+	for _, index := range indexes {
+		if index.Name == "" || index.Values == nil {
+			return nil, fmt.Errorf("index %q of %s needs a name and values: %w", index.Name, prefix, sdk.ErrInvalidArgument)
+		}
+		if names[index.Name] {
+			return nil, fmt.Errorf("index %q of %s: %w", index.Name, prefix, sdk.ErrDuplicateKey)
+		}
+		names[index.Name] = true
+	}
+	indexKey, _ := sdk.CreateCompositeKey(prefix, "index")
+	return &IndexedMap[K, V]{
+		records:  NewMap(ctx, prefix, keyCodec, valueCodec),
+		indexKey: indexKey,
+		indexes:  indexes,
+	}, nil
+}
+
+// Get return the value of key and whether it exists
+func (m *IndexedMap[K, V]) Get(key K) (V, bool, error) {
+	return m.records.Get(key)
+}
+
+// Has return whether key exists
+func (m *IndexedMap[K, V]) Has(key K) (bool, error) {
+	return m.records.Has(key)
+}
+
+// Iterate call fn for every record in key order until fn returns false
+func (m *IndexedMap[K, V]) Iterate(fn func(key K, value V) bool) error {
+	return m.records.Iterate(fn)
+}
+
+// Set put value to key and update the index entries. A unique index already holding one of the
+// values for another key is sdk.ErrDuplicateKey, and nothing is written
+func (m *IndexedMap[K, V]) Set(key K, value V) error {
+	field, err := encodeKey(m.records.keyCodec, key)
+	if err != nil {
+		return err
+	}
+	old, exists, err := m.Get(key)
+	if err != nil {
+		return err
+	}
+	var oldFields map[string]bool
+	if exists {
+		if oldFields, err = m.indexFields(field, old); err != nil {
+			return err
+		}
+	}
+	newFields, err := m.indexFields(field, value)
+	if err != nil {
+		return err
+	}
+	// check every unique value before the first write
+	for _, indexField := range sortedFields(newFields) {
+		if oldFields[indexField] {
+			continue
+		}
+		owner, taken, err := m.records.ctx.GetStateWithExistsE(m.indexKey, indexField)
+		if err != nil {
+			return err
+		}
+		if taken && string(owner) != field {
+			return fmt.Errorf("index entry %s of %s: %w", indexField, m.records.prefix, sdk.ErrDuplicateKey)
+		}
+	}
+	for _, indexField := range sortedFields(oldFields) {
+		if !newFields[indexField] {
+			if err = m.records.ctx.DeleteStateE(m.indexKey, indexField); err != nil {
+				return err
+			}
+		}
+	}
+	for _, indexField := range sortedFields(newFields) {
+		if !oldFields[indexField] {
+			if err = m.records.ctx.PutStateByteE(m.indexKey, indexField, []byte(field)); err != nil {
+				return err
+			}
+		}
+	}
+	return m.records.Set(key, value)
+}
+
+// Delete remove key and its index entries
+func (m *IndexedMap[K, V]) Delete(key K) error {
+	field, err := encodeKey(m.records.keyCodec, key)
+	if err != nil {
+		return err
+	}
+	old, exists, err := m.Get(key)
+	if err != nil || !exists {
+		return err
+	}
+	oldFields, err := m.indexFields(field, old)
+	if err != nil {
+		return err
+	}
+	for _, indexField := range sortedFields(oldFields) {
+		if err = m.records.ctx.DeleteStateE(m.indexKey, indexField); err != nil {
+			return err
+		}
+	}
+	return m.records.Delete(key)
+}
+
+// LookupBy return the keys of the records having value in index, in index order
+func (m *IndexedMap[K, V]) LookupBy(index string, value interface{}) ([]K, error) {
+	if _, ok := m.index(index); !ok {
+		return nil, fmt.Errorf("index %q of %s: %w", index, m.records.prefix, sdk.ErrNotFound)
+	}
+	prefix, err := sdk.CreateCompositeKey(index, value)
+	if err != nil {
+		return nil, err
+	}
+	rs, code := m.records.ctx.NewIteratorPrefixWithKeyField(m.indexKey, prefix)
+	if code != sdk.SUCCESS {
+		return nil, &sdk.ErrHostCall{Method: sdk.ContractMethodKvPreIterator, Code: int32(code)}
+	}
+	var keys []K
+	iterErr := sdk.ForEachKV(rs, func(key string, field string, data []byte) bool {
+		if key != m.indexKey {
+			return true
+		}
+		var k K
+		if k, err = m.records.keyCodec.DecodeKey(string(data)); err != nil {
+			return false
+		}
+		keys = append(keys, k)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return keys, iterErr
+}
+
+func (m *IndexedMap[K, V]) index(name string) (Index[V], bool) {
+	for _, index := range m.indexes {
+		if index.Name == name {
+			return index, true
+		}
+	}
+	return Index[V]{}, false
+}
+
+// indexFields the fields of the index entries of the record [field, value]. A unique entry is
+// [name, value] and holds the record field, a non unique entry also ends with the record field
+func (m *IndexedMap[K, V]) indexFields(field string, value V) (map[string]bool, error) {
+	fields := make(map[string]bool)
+	for _, index := range m.indexes {
+		for _, v := range index.Values(value) {
+			parts := []interface{}{v}
+			if !index.Unique {
+				parts = append(parts, field)
+			}
+			indexField, err := sdk.CreateCompositeKey(index.Name, parts...)
+			if err != nil {
+				return nil, fmt.Errorf("index %q of %s: %w", index.Name, m.records.prefix, err)
+			}
+			fields[indexField] = true
+		}
+	}
+	return fields, nil
+}
+
+// sortedFields the fields in order, keeping the writes deterministic
+func sortedFields(fields map[string]bool) []string {
+	sorted := make([]string, 0, len(fields))
+	for field := range fields {
+		sorted = append(sorted, field)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
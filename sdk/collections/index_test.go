@@ -0,0 +1,139 @@
+//go:build !wasm
+// +build !wasm
+
+/*
+Copyright (C) BABEC. All rights reserved.
+Copyright (C) THL A29 Limited, a Tencent company. All rights reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package collections
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/TKOTKCh/contract-sdk-go-wasm/sdk"
+)
+
+// users map of user id to "email,city", uniquely indexed by email and indexed by city
+func users(t *testing.T, ctx sdk.SimContext) *IndexedMap[string, string] {
+	t.Helper()
+	m, err := NewIndexedMap(ctx, "users", StringKey, StringValue,
+		Index[string]{Name: "email", Unique: true, Values: func(v string) []interface{} {
+			return []interface{}{strings.Split(v, ",")[0]}
+		}},
+		Index[string]{Name: "city", Values: func(v string) []interface{} {
+			return []interface{}{strings.Split(v, ",")[1]}
+		}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func lookup(t *testing.T, m *IndexedMap[string, string], index string, value interface{}) []string {
+	t.Helper()
+	keys, err := m.LookupBy(index, value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keys
+}
+
+func TestNewIndexedMapErrors(t *testing.T) {
+	values := func(v string) []interface{} { return []interface{}{v} }
+	tests := []struct {
+		name    string
+		indexes []Index[string]
+		want    error
+	}{
+		{"no name", []Index[string]{{Values: values}}, sdk.ErrInvalidArgument},
+		{"no values", []Index[string]{{Name: "email"}}, sdk.ErrInvalidArgument},
+		{"same name", []Index[string]{{Name: "email", Values: values}, {Name: "email", Values: values}},
+			sdk.ErrDuplicateKey},
+	}
+	for _, tt := range tests {
+		_, err := NewIndexedMap(sdk.NewSimContext(), "users", StringKey, StringValue, tt.indexes...)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestIndexedMapUniqueConflictWritesNothing(t *testing.T) {
+	result := invoke(t, func(ctx sdk.SimContext) {
+		m := users(t, ctx)
+		if err := m.Set("u1", "a@x,paris"); err != nil {
+			t.Fatal(err)
+		}
+		if err := m.Set("u2", "a@x,rome"); !errors.Is(err, sdk.ErrDuplicateKey) {
+			t.Fatalf("got %v, want ErrDuplicateKey", err)
+		}
+		if ok, _ := m.Has("u2"); ok {
+			t.Fatal("the conflicting record was written")
+		}
+		if got := lookup(t, m, "city", "rome"); len(got) != 0 {
+			t.Fatalf("city rome %v", got)
+		}
+		// setting the same unique value again for its owner is not a conflict
+		if err := m.Set("u1", "a@x,rome"); err != nil {
+			t.Fatal(err)
+		}
+	})
+	for _, w := range result.WriteSet {
+		if strings.Contains(w.Field, "u2") || string(w.Value) == "u2" {
+			t.Fatalf("write set %+v holds the conflicting record", result.WriteSet)
+		}
+	}
+}
+
+func TestIndexedMapReindexOnUpdate(t *testing.T) {
+	invoke(t, func(ctx sdk.SimContext) {
+		m := users(t, ctx)
+		m.Set("u1", "a@x,paris")
+		if err := m.Set("u1", "b@x,rome"); err != nil {
+			t.Fatal(err)
+		}
+		if got := lookup(t, m, "email", "a@x"); len(got) != 0 {
+			t.Fatalf("old email still indexed %v", got)
+		}
+		if got := lookup(t, m, "city", "paris"); len(got) != 0 {
+			t.Fatalf("old city still indexed %v", got)
+		}
+		if got := lookup(t, m, "email", "b@x"); !reflect.DeepEqual(got, []string{"u1"}) {
+			t.Fatalf("new email %v", got)
+		}
+		// the old unique value is free again
+		if err := m.Set("u2", "a@x,paris"); err != nil {
+			t.Fatal(err)
+		}
+		if err := m.Delete("u1"); err != nil {
+			t.Fatal(err)
+		}
+		if got := lookup(t, m, "city", "rome"); len(got) != 0 {
+			t.Fatalf("deleted record still indexed %v", got)
+		}
+	})
+}
+
+func TestIndexedMapLookupNonUnique(t *testing.T) {
+	invoke(t, func(ctx sdk.SimContext) {
+		m := users(t, ctx)
+		m.Set("u3", "c@x,paris")
+		m.Set("u1", "a@x,paris")
+		m.Set("u2", "b@x,rome")
+		// a value prefixing another is not matched
+		m.Set("u4", "d@x,parisian")
+		if got := lookup(t, m, "city", "paris"); !reflect.DeepEqual(got, []string{"u1", "u3"}) {
+			t.Fatalf("city paris %v", got)
+		}
+		if _, err := m.LookupBy("country", "fr"); !errors.Is(err, sdk.ErrNotFound) {
+			t.Fatalf("unknown index: got %v", err)
+		}
+	})
+}
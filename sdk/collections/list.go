@@ -0,0 +1,96 @@
+/*
+Copyright (C) BABEC. All rights reserved.
+Copyright (C) THL A29 Limited, a Tencent company. All rights reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package collections
+
+import (
+	"fmt"
+
+	"github.com/TKOTKCh/contract-sdk-go-wasm/sdk"
+)
+
+const listLenField = "len"
+
+// List persistent append-only list stored under prefix
+type List[V any] struct {
+	ctx        sdk.StateContextE
+	prefix     string
+	valueCodec ValueCodec[V]
+}
+
+// NewList create a List stored under prefix
+func NewList[V any](ctx sdk.SimContext, prefix string, valueCodec ValueCodec[V]) *List[V] {
+	return &List[V]{ctx: sdk.AsStateContextE(ctx), prefix: prefix, valueCodec: valueCodec}
+}
+
+// Len return the number of values
+func (l *List[V]) Len() (uint64, error) {
+	return getCounter(l.ctx, l.prefix, listLenField)
+}
+
+// Append add value at the end and return its index
+func (l *List[V]) Append(value V) (uint64, error) {
+	n, err := l.Len()
+	if err != nil {
+		return 0, err
+	}
+	data, err := l.valueCodec.Encode(value)
+	if err != nil {
+		return 0, err
+	}
+	if err = l.ctx.PutStateByteE(l.prefix, positionField(n), data); err != nil {
+		return 0, err
+	}
+	return n, putCounter(l.ctx, l.prefix, listLenField, n+1)
+}
+
+// Get return the value at index, an index out of range is sdk.ErrNotFound
+func (l *List[V]) Get(index uint64) (V, error) {
+	var zero V
+	data, exists, err := l.ctx.GetStateWithExistsE(l.prefix, positionField(index))
+	if err != nil {
+		return zero, err
+	}
+	if !exists {
+		return zero, fmt.Errorf("list %s index %d: %w", l.prefix, index, sdk.ErrNotFound)
+	}
+	return l.valueCodec.Decode(data)
+}
+
+// Set replace the value at index, an index out of range is sdk.ErrNotFound
+func (l *List[V]) Set(index uint64, value V) error {
+	n, err := l.Len()
+	if err != nil {
+		return err
+	}
+	if index >= n {
+		return fmt.Errorf("list %s index %d: %w", l.prefix, index, sdk.ErrNotFound)
+	}
+	data, err := l.valueCodec.Encode(value)
+	if err != nil {
+		return err
+	}
+	return l.ctx.PutStateByteE(l.prefix, positionField(index), data)
+}
+
+// Iterate call fn for every value in index order until fn returns false
+func (l *List[V]) Iterate(fn func(index uint64, value V) bool) error {
+	n, err := l.Len()
+	if err != nil {
+		return err
+	}
+	for i := uint64(0); i < n; i++ {
+		value, err := l.Get(i)
+		if err != nil {
+			return err
+		}
+		if !fn(i, value) {
+			return nil
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+/*
+Copyright (C) BABEC. All rights reserved.
+Copyright (C) THL A29 Limited, a Tencent company. All rights reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package collections
+
+import "github.com/TKOTKCh/contract-sdk-go-wasm/sdk"
+
+// Map persistent map stored under prefix
+type Map[K any, V any] struct {
+	ctx        sdk.StateContextE
+	prefix     string
+	keyCodec   KeyCodec[K]
+	valueCodec ValueCodec[V]
+}
+
+// NewMap create a Map stored under prefix
+func NewMap[K any, V any](ctx sdk.SimContext, prefix string, keyCodec KeyCodec[K], valueCodec ValueCodec[V]) *Map[K, V] {
+	return &Map[K, V]{ctx: sdk.AsStateContextE(ctx), prefix: prefix, keyCodec: keyCodec, valueCodec: valueCodec}
+}
+
+// Get return the value of key and whether it exists
+func (m *Map[K, V]) Get(key K) (V, bool, error) {
+	var zero V
+	field, err := encodeKey(m.keyCodec, key)
+	if err != nil {
+		return zero, false, err
+	}
+	data, exists, err := m.ctx.GetStateWithExistsE(m.prefix, field)
+	if err != nil || !exists {
+		return zero, false, err
+	}
+	value, err := m.valueCodec.Decode(data)
+	if err != nil {
+		return zero, true, err
+	}
+	return value, true, nil
+}
+
+// Has return whether key exists
+func (m *Map[K, V]) Has(key K) (bool, error) {
+	field, err := encodeKey(m.keyCodec, key)
+	if err != nil {
+		return false, err
+	}
+	_, exists, err := m.ctx.GetStateWithExistsE(m.prefix, field)
+	return exists, err
+}
+
+// Set put value to key
+func (m *Map[K, V]) Set(key K, value V) error {
+	field, err := encodeKey(m.keyCodec, key)
+	if err != nil {
+		return err
+	}
+	data, err := m.valueCodec.Encode(value)
+	if err != nil {
+		return err
+	}
+	return m.ctx.PutStateByteE(m.prefix, field, data)
+}
+
+// Delete remove key
+func (m *Map[K, V]) Delete(key K) error {
+	field, err := encodeKey(m.keyCodec, key)
+	if err != nil {
+		return err
+	}
+	return m.ctx.DeleteStateE(m.prefix, field)
+}
+
+// Iterate call fn for every entry in key order until fn returns false
+func (m *Map[K, V]) Iterate(fn func(key K, value V) bool) error {
+	return iterate(m.ctx, m.prefix, func(field string, data []byte) (bool, error) {
+		key, err := m.keyCodec.DecodeKey(field)
+		if err != nil {
+			return false, err
+		}
+		value, err := m.valueCodec.Decode(data)
+		if err != nil {
+			return false, err
+		}
+		return fn(key, value), nil
+	})
+}
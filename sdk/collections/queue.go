@@ -0,0 +1,102 @@
+/*
+Copyright (C) BABEC. All rights reserved.
+Copyright (C) THL A29 Limited, a Tencent company. All rights reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package collections
+
+import (
+	"fmt"
+
+	"github.com/TKOTKCh/contract-sdk-go-wasm/sdk"
+)
+
+const (
+	queueHeadField = "head"
+	queueTailField = "tail"
+)
+
+// Queue persistent FIFO queue stored under prefix, values live at positions [head, tail)
+type Queue[V any] struct {
+	ctx        sdk.StateContextE
+	prefix     string
+	valueCodec ValueCodec[V]
+}
+
+// NewQueue create a Queue stored under prefix
+func NewQueue[V any](ctx sdk.SimContext, prefix string, valueCodec ValueCodec[V]) *Queue[V] {
+	return &Queue[V]{ctx: sdk.AsStateContextE(ctx), prefix: prefix, valueCodec: valueCodec}
+}
+
+func (q *Queue[V]) bounds() (uint64, uint64, error) {
+	head, err := getCounter(q.ctx, q.prefix, queueHeadField)
+	if err != nil {
+		return 0, 0, err
+	}
+	tail, err := getCounter(q.ctx, q.prefix, queueTailField)
+	return head, tail, err
+}
+
+// Len return the number of values
+func (q *Queue[V]) Len() (uint64, error) {
+	head, tail, err := q.bounds()
+	return tail - head, err
+}
+
+// Push add value at the tail
+func (q *Queue[V]) Push(value V) error {
+	_, tail, err := q.bounds()
+	if err != nil {
+		return err
+	}
+	data, err := q.valueCodec.Encode(value)
+	if err != nil {
+		return err
+	}
+	if err = q.ctx.PutStateByteE(q.prefix, positionField(tail), data); err != nil {
+		return err
+	}
+	return putCounter(q.ctx, q.prefix, queueTailField, tail+1)
+}
+
+// Peek return the value at the head without removing it, false when empty
+func (q *Queue[V]) Peek() (V, bool, error) {
+	var zero V
+	head, tail, err := q.bounds()
+	if err != nil || head == tail {
+		return zero, false, err
+	}
+	value, err := q.get(head)
+	return value, err == nil, err
+}
+
+// Pop remove and return the value at the head, false when empty
+func (q *Queue[V]) Pop() (V, bool, error) {
+	var zero V
+	head, tail, err := q.bounds()
+	if err != nil || head == tail {
+		return zero, false, err
+	}
+	value, err := q.get(head)
+	if err != nil {
+		return zero, false, err
+	}
+	if err = q.ctx.DeleteStateE(q.prefix, positionField(head)); err != nil {
+		return zero, false, err
+	}
+	return value, true, putCounter(q.ctx, q.prefix, queueHeadField, head+1)
+}
+
+func (q *Queue[V]) get(position uint64) (V, error) {
+	var zero V
+	data, exists, err := q.ctx.GetStateWithExistsE(q.prefix, positionField(position))
+	if err != nil {
+		return zero, err
+	}
+	if !exists {
+		return zero, fmt.Errorf("queue %s position %d: %w", q.prefix, position, sdk.ErrNotFound)
+	}
+	return q.valueCodec.Decode(data)
+}
@@ -0,0 +1,63 @@
+/*
+Copyright (C) BABEC. All rights reserved.
+Copyright (C) THL A29 Limited, a Tencent company. All rights reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package collections
+
+import "github.com/TKOTKCh/contract-sdk-go-wasm/sdk"
+
+var setMember = []byte("1")
+
+// Set persistent set stored under prefix
+type Set[K any] struct {
+	ctx      sdk.StateContextE
+	prefix   string
+	keyCodec KeyCodec[K]
+}
+
+// NewSet create a Set stored under prefix
+func NewSet[K any](ctx sdk.SimContext, prefix string, keyCodec KeyCodec[K]) *Set[K] {
+	return &Set[K]{ctx: sdk.AsStateContextE(ctx), prefix: prefix, keyCodec: keyCodec}
+}
+
+// Has return whether key is in the set
+func (s *Set[K]) Has(key K) (bool, error) {
+	field, err := encodeKey(s.keyCodec, key)
+	if err != nil {
+		return false, err
+	}
+	_, exists, err := s.ctx.GetStateWithExistsE(s.prefix, field)
+	return exists, err
+}
+
+// Add add key to the set
+func (s *Set[K]) Add(key K) error {
+	field, err := encodeKey(s.keyCodec, key)
+	if err != nil {
+		return err
+	}
+	return s.ctx.PutStateByteE(s.prefix, field, setMember)
+}
+
+// Remove remove key from the set
+func (s *Set[K]) Remove(key K) error {
+	field, err := encodeKey(s.keyCodec, key)
+	if err != nil {
+		return err
+	}
+	return s.ctx.DeleteStateE(s.prefix, field)
+}
+
+// Iterate call fn for every key in order until fn returns false
+func (s *Set[K]) Iterate(fn func(key K) bool) error {
+	return iterate(s.ctx, s.prefix, func(field string, _ []byte) (bool, error) {
+		key, err := s.keyCodec.DecodeKey(field)
+		if err != nil {
+			return false, err
+		}
+		return fn(key), nil
+	})
+}
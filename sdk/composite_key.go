@@ -0,0 +1,165 @@
+/*
+Copyright (C) BABEC. All rights reserved.
+Copyright (C) THL A29 Limited, a Tencent company. All rights reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package sdk
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Composite keys join an object type and parts into one state key or field, which the chain
+// accepts whatever the parts contain: every part is followed by '.', and any byte out of
+// [a-zA-Z0-9-] is escaped as '_' and two upper case hex digits, so "a.b" and "a_b" give
+// "a_2Eb." and "a_5Fb.". As each part ends with the separator, the key of a part is never the
+// prefix of the key of a longer part: "order.ab." does not start with "order.a."
+//
+// Integer parts are 20 digits keeping the numeric order, negative numbers first, so ranges
+// over them are numeric ranges. Escaped strings do not keep the byte order of the raw strings.
+const (
+	compositeKeySeparator = '.'
+	compositeKeyEscape    = '_'
+)
+
+// CreateCompositeKey join objectType and parts, which are string, []byte, int, int32, int64,
+// uint, uint32 or uint64. The result is a valid key or field of the chain
+func CreateCompositeKey(objectType string, parts ...interface{}) (string, error) {
+	var b strings.Builder
+	appendKeyPart(&b, objectType)
+	for i, part := range parts {
+		s, err := compositeKeyPart(part)
+		if err != nil {
+			return "", fmt.Errorf("part %d: %w", i, err)
+		}
+		appendKeyPart(&b, s)
+	}
+	return b.String(), nil
+}
+
+// SplitCompositeKey split a key of CreateCompositeKey into its object type and parts, integer
+// parts are returned as their 20 digits, see ParseInt64Part and ParseUint64Part. A key not built
+// by CreateCompositeKey is ErrCodec
+func SplitCompositeKey(compositeKey string) (string, []string, error) {
+	if compositeKey == "" || compositeKey[len(compositeKey)-1] != compositeKeySeparator {
+		return "", nil, fmt.Errorf("composite key %q does not end with a separator: %w", compositeKey, ErrCodec)
+	}
+	var parts []string
+	var part []byte
+	for i := 0; i < len(compositeKey); i++ {
+		c := compositeKey[i]
+		switch {
+		case c == compositeKeySeparator:
+			parts = append(parts, string(part))
+			part = part[:0]
+		case c == compositeKeyEscape:
+			if i+2 >= len(compositeKey) {
+				return "", nil, fmt.Errorf("composite key %q: truncated escape: %w", compositeKey, ErrCodec)
+			}
+			v, err := strconv.ParseUint(compositeKey[i+1:i+3], 16, 8)
+			if err != nil || keepKeyByte(byte(v)) || strings.ToUpper(compositeKey[i+1:i+3]) != compositeKey[i+1:i+3] {
+				return "", nil, fmt.Errorf("composite key %q: bad escape %q: %w", compositeKey, compositeKey[i:i+3], ErrCodec)
+			}
+			part = append(part, byte(v))
+			i += 2
+		case keepKeyByte(c):
+			part = append(part, c)
+		default:
+			return "", nil, fmt.Errorf("composite key %q: invalid byte %q: %w", compositeKey, c, ErrCodec)
+		}
+	}
+	return parts[0], parts[1:], nil
+}
+
+// CompositeKeyRange return the range [startKey, limitKey) of exactly the composite keys starting
+// with objectType and parts, to use with NewIterator, or NewIteratorWithField when composite
+// keys are fields. The bounds are valid keys of the chain
+func CompositeKeyRange(objectType string, parts ...interface{}) (string, string, error) {
+	prefix, err := CreateCompositeKey(objectType, parts...)
+	if err != nil {
+		return "", "", err
+	}
+	// '0' follows '.' among the bytes of a composite key
+	return prefix, prefix[:len(prefix)-1] + "0", nil
+}
+
+// ParseInt64Part parse an int64 part of SplitCompositeKey
+func ParseInt64Part(part string) (int64, error) {
+	n, err := parseIntPart(part)
+	if err != nil {
+		return 0, err
+	}
+	return int64(n ^ (1 << 63)), nil
+}
+
+// ParseUint64Part parse a uint64 part of SplitCompositeKey
+func ParseUint64Part(part string) (uint64, error) {
+	return parseIntPart(part)
+}
+
+func parseIntPart(part string) (uint64, error) {
+	if len(part) != 20 {
+		return 0, fmt.Errorf("integer part %q is not 20 digits: %w", part, ErrCodec)
+	}
+	n, err := strconv.ParseUint(part, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("integer part %q: %v: %w", part, err, ErrCodec)
+	}
+	return n, nil
+}
+
+// compositeKeyPart the raw string of a typed part
+func compositeKeyPart(part interface{}) (string, error) {
+	switch v := part.(type) {
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	case int:
+		return int64Part(int64(v)), nil
+	case int32:
+		return int64Part(int64(v)), nil
+	case int64:
+		return int64Part(v), nil
+	case uint:
+		return uint64Part(uint64(v)), nil
+	case uint32:
+		return uint64Part(uint64(v)), nil
+	case uint64:
+		return uint64Part(v), nil
+	}
+	return "", fmt.Errorf("unsupported composite key part %T: %w", part, ErrCodec)
+}
+
+func int64Part(n int64) string {
+	return uint64Part(uint64(n) ^ (1 << 63))
+}
+
+func uint64Part(n uint64) string {
+	return fmt.Sprintf("%020d", n)
+}
+
+// appendKeyPart append the escaped part and its separator
+func appendKeyPart(b *strings.Builder, part string) {
+	const hex = "0123456789ABCDEF"
+	for i := 0; i < len(part); i++ {
+		c := part[i]
+		if keepKeyByte(c) {
+			b.WriteByte(c)
+		} else {
+			b.WriteByte(compositeKeyEscape)
+			b.WriteByte(hex[c>>4])
+			b.WriteByte(hex[c&0xf])
+		}
+	}
+	b.WriteByte(compositeKeySeparator)
+}
+
+// keepKeyByte whether c is written as is in a composite key
+func keepKeyByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-'
+}
@@ -0,0 +1,120 @@
+/*
+Copyright (C) BABEC. All rights reserved.
+Copyright (C) THL A29 Limited, a Tencent company. All rights reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package sdk
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestCompositeKeyEscaping(t *testing.T) {
+	tests := []struct {
+		objectType string
+		parts      []interface{}
+		want       string
+	}{
+		{"order", nil, "order."},
+		{"order", []interface{}{"a.b", "a_b"}, "order.a_2Eb.a_5Fb."},
+		{"o-1", []interface{}{"", []byte{0, 0xff}}, "o-1.._00_FF."},
+		{"n", []interface{}{int32(-1), uint32(1)}, "n.09223372036854775807.00000000000000000001."},
+	}
+	for _, tt := range tests {
+		key, err := CreateCompositeKey(tt.objectType, tt.parts...)
+		if err != nil || key != tt.want {
+			t.Fatalf("create %q %v: %q, %v, want %q", tt.objectType, tt.parts, key, err, tt.want)
+		}
+		objectType, parts, err := SplitCompositeKey(key)
+		if err != nil || objectType != tt.objectType || len(parts) != len(tt.parts) {
+			t.Fatalf("split %q: %q %q, %v", key, objectType, parts, err)
+		}
+		for i, part := range tt.parts {
+			if s, ok := part.(string); ok && parts[i] != s {
+				t.Fatalf("split %q: part %d %q, want %q", key, i, parts[i], s)
+			}
+		}
+	}
+
+	if _, err := CreateCompositeKey("t", 1.5); !errors.Is(err, ErrCodec) {
+		t.Fatalf("float part: got %v, want ErrCodec", err)
+	}
+}
+
+func TestSplitCompositeKeyRejectsNonCanonical(t *testing.T) {
+	for _, key := range []string{
+		"",
+		"order",
+		"order.a_2eb.", // lower case hex
+		"order._61.",   // escaped kept byte
+		"order._2D.",   // escaped '-'
+		"order.a_2",    // truncated escape
+		"order.a_2.",   // truncated escape before the separator
+		"order.a_ZZ.",  // not hex
+		"order.a b.",   // unescaped byte
+	} {
+		if _, _, err := SplitCompositeKey(key); !errors.Is(err, ErrCodec) {
+			t.Errorf("split %q: got %v, want ErrCodec", key, err)
+		}
+	}
+}
+
+func TestCompositeKeyInt64Order(t *testing.T) {
+	values := []int64{math.MaxInt64, 0, -1, math.MinInt64, 1, -1000, 999}
+	keys := make([]string, len(values))
+	for i, v := range values {
+		keys[i], _ = CreateCompositeKey("n", v)
+	}
+	sort.Strings(keys)
+	var got []int64
+	for _, key := range keys {
+		_, parts, err := SplitCompositeKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v, err := ParseInt64Part(parts[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, v)
+	}
+	want := []int64{math.MinInt64, -1000, -1, 0, 1, 999, math.MaxInt64}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if _, err := ParseInt64Part("123"); !errors.Is(err, ErrCodec) {
+		t.Fatalf("short part: got %v", err)
+	}
+}
+
+func TestCompositeKeyRangeExact(t *testing.T) {
+	start, limit, err := CompositeKeyRange("order", "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	inside := [][]interface{}{{"a"}, {"a", ""}, {"a", "x"}, {"a", "x.y"}, {"a", int64(-1)}}
+	outside := [][]interface{}{{""}, {"a-"}, {"a."}, {"a0"}, {"aB"}, {"ab"}, {"A"}, {"b"}, {"a_"}}
+	for _, parts := range inside {
+		key, _ := CreateCompositeKey("order", parts...)
+		if key < start || key >= limit {
+			t.Errorf("%q is out of [%q, %q)", key, start, limit)
+		}
+	}
+	for _, parts := range outside {
+		key, _ := CreateCompositeKey("order", parts...)
+		if key >= start && key < limit {
+			t.Errorf("%q is in [%q, %q)", key, start, limit)
+		}
+	}
+	for _, key := range []string{"order", "orders.a.", "order-.a."} {
+		if key >= start && key < limit {
+			t.Errorf("%q is in [%q, %q)", key, start, limit)
+		}
+	}
+}
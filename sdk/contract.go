@@ -0,0 +1,152 @@
+/*
+Copyright (C) BABEC. All rights reserved.
+Copyright (C) THL A29 Limited, a Tencent company. All rights reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package sdk
+
+const (
+	// MethodInitContract lifecycle method called when the contract is installed
+	MethodInitContract = "init_contract"
+	// MethodUpgrade lifecycle method called when the contract is upgraded
+	MethodUpgrade = "upgrade"
+)
+
+// Handler handle a contract method, it returns Success or Error: the zero Response has no Status
+// and is reported as an error
+type Handler func(ctx SimContext) Response
+
+// Contract routes the calls of the VM to the registered methods, as:
+//
+//	func main() {
+//		c := sdk.NewContract()
+//		c.Register(sdk.MethodInitContract, initContract)
+//		c.Register("transfer", transfer)
+//		sdk.Serve(c)
+//	}
+//
+// The entries are exported by package entry.
+type Contract struct {
+	methods    map[string]Handler
+	stateCache bool
+}
+
+// NewContract create a Contract without methods
+func NewContract() *Contract {
+	return &Contract{methods: make(map[string]Handler)}
+}
+
+// Register register handler for method, registering the same method twice panics.
+// MethodInitContract and MethodUpgrade can be registered but are only reachable through
+// their own entries, when not registered they succeed with an empty payload
+func (c *Contract) Register(method string, handler Handler) {
+	if method == "" || handler == nil {
+		panic("sdk: invalid method registration")
+	}
+	if _, ok := c.methods[method]; ok {
+		panic("sdk: method " + method + " registered twice")
+	}
+	c.methods[method] = handler
+}
+
+// UseStateCache give the handlers a CachedSimContext, flushed after each successful method with the
+// batch sys_calls, or key by key on a host without them
+func (c *Contract) UseStateCache() {
+	c.stateCache = true
+}
+
+// Invoke call the handler of method with a new SimContext and report its Response
+func (c *Contract) Invoke(method string) Response {
+	response := c.call(method, true)
+	response.report()
+	return response
+}
+
+// call run the handler of method, the results recorded through the SimContext are merged
+// into the returned Response. The state cache is flushed only when the merged Response succeeds
+func (c *Contract) call(method string, lifecycle bool) Response {
+	recorder = &resultRecorder{}
+	defer func() {
+		recorder = nil
+	}()
+	handler, response := c.handler(method, lifecycle)
+	if handler == nil {
+		return recorder.merge(response)
+	}
+	if !c.stateCache {
+		return recorder.merge(checkStatus(method, handler(NewSimContext())))
+	}
+	ctx := NewCachedSimContext()
+	response = recorder.merge(checkStatus(method, handler(ctx)))
+	if response.IsError() {
+		return response
+	}
+	if err := ctx.FlushE(); err != nil {
+		return Error(StatusError, "flush state cache: "+err.Error())
+	}
+	return response
+}
+
+// handler return the handler of method, or nil and the Response of a method without handler
+func (c *Contract) handler(method string, lifecycle bool) (Handler, Response) {
+	if isLifecycleMethod(method) && !lifecycle {
+		return nil, Error(StatusError, "lifecycle method "+method+" can not be invoked")
+	}
+	handler, ok := c.methods[method]
+	if !ok {
+		if isLifecycleMethod(method) {
+			return nil, Success(nil)
+		}
+		return nil, Error(StatusError, "unknown method "+method)
+	}
+	return handler, Response{}
+}
+
+// checkStatus return response, or an error naming method when it has no Status, as the zero Response
+func checkStatus(method string, response Response) Response {
+	if response.Status == 0 {
+		return Error(StatusError, "method "+method+" returned a Response without status, return sdk.Success or sdk.Error")
+	}
+	return response
+}
+
+func isLifecycleMethod(method string) bool {
+	return method == MethodInitContract || method == MethodUpgrade
+}
+
+var servedContract *Contract
+
+// Serve set c as the contract dispatched by the exported entries
+func Serve(c *Contract) {
+	servedContract = c
+}
+
+// Dispatch call method of the served contract and report its Response, used by the lifecycle entries
+func Dispatch(method string) {
+	if servedContract == nil {
+		ErrorResult("no contract served, call sdk.Serve")
+		return
+	}
+	servedContract.Invoke(method)
+}
+
+// InvokeContract call the method named by the ContractParamMethod arg on the served contract.
+// The host calling the invoke_contract entry must pass the name of the contract method in the
+// "__method__" arg, next to the args of the method. A host calling the export named as the
+// method sets no such arg, InvokeContract then reports "missing arg __method__", and the
+// contract must hand-write its exports calling Contract.Invoke instead
+func InvokeContract() {
+	if servedContract == nil {
+		ErrorResult("no contract served, call sdk.Serve")
+		return
+	}
+	method, code := ArgString(ContractParamMethod)
+	if code != SUCCESS || method == "" {
+		ErrorResult("missing arg " + ContractParamMethod)
+		return
+	}
+	response := servedContract.call(method, false)
+	response.report()
+}
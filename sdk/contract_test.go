@@ -0,0 +1,91 @@
+//go:build !wasm
+// +build !wasm
+
+/*
+Copyright (C) BABEC. All rights reserved.
+Copyright (C) THL A29 Limited, a Tencent company. All rights reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package sdk_test
+
+import (
+	"testing"
+
+	"github.com/TKOTKCh/contract-sdk-go-wasm/sdk"
+	"github.com/TKOTKCh/contract-sdk-go-wasm/sdk/mock"
+)
+
+// greeter contract with a hello method and a method returning the zero Response
+func greeter() *sdk.Contract {
+	c := sdk.NewContract()
+	c.Register("hello", func(ctx sdk.SimContext) sdk.Response {
+		name, _ := ctx.ArgString("name")
+		return sdk.Success([]byte("hi " + name))
+	})
+	c.Register("zero", func(ctx sdk.SimContext) sdk.Response {
+		return sdk.Response{}
+	})
+	return c
+}
+
+// invokeEntry call the invoke_contract entry of c with args, as the VM does
+func invokeEntry(c *sdk.Contract, args map[string][]byte) *mock.Result {
+	return mock.NewChain().Invoke(func() {
+		sdk.Serve(c)
+		sdk.InvokeContract()
+	}, args)
+}
+
+func TestContractDispatch(t *testing.T) {
+	chain := mock.NewChain()
+	r := chain.InvokeContract(greeter(), "hello", map[string][]byte{"name": []byte("bob")})
+	if r.IsError || string(r.Payload) != "hi bob" {
+		t.Fatalf("hello %+v", r)
+	}
+	r = chain.InvokeContract(greeter(), "nope", nil)
+	if !r.IsError || r.Message != "unknown method nope" {
+		t.Fatalf("unknown method %+v", r)
+	}
+	r = chain.InvokeContract(greeter(), "zero", nil)
+	if !r.IsError || r.Message == "" {
+		t.Fatalf("zero Response %+v", r)
+	}
+}
+
+func TestContractLifecycle(t *testing.T) {
+	chain := mock.NewChain()
+	for _, method := range []string{sdk.MethodInitContract, sdk.MethodUpgrade} {
+		if r := chain.InvokeContract(greeter(), method, nil); r.IsError || len(r.Payload) != 0 {
+			t.Errorf("unregistered %s %+v", method, r)
+		}
+		c := greeter()
+		c.Register(method, func(ctx sdk.SimContext) sdk.Response {
+			return sdk.Success([]byte("ran"))
+		})
+		if r := chain.InvokeContract(c, method, nil); r.IsError || string(r.Payload) != "ran" {
+			t.Errorf("registered %s %+v", method, r)
+		}
+		r := invokeEntry(c, map[string][]byte{sdk.ContractParamMethod: []byte(method)})
+		if !r.IsError || r.Message != "lifecycle method "+method+" can not be invoked" {
+			t.Errorf("%s through invoke_contract %+v", method, r)
+		}
+	}
+}
+
+func TestContractMissingMethodArg(t *testing.T) {
+	r := invokeEntry(greeter(), map[string][]byte{"name": []byte("bob")})
+	if !r.IsError || r.Message != "missing arg "+sdk.ContractParamMethod {
+		t.Fatalf("got %+v", r)
+	}
+}
+
+func TestContractRegisterTwicePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("no panic")
+		}
+	}()
+	greeter().Register("hello", func(ctx sdk.SimContext) sdk.Response { return sdk.Success(nil) })
+}
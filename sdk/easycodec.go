@@ -18,6 +18,20 @@ serialization format :
 	valType:  	byte[4], le int32
 	valLen:  	byte[4], le int32
 	val:  		byte[valLen]
+
+the header magicNum + ecVersion + reserved is optional: EasyMarshal omits it, EasyMarshalWithHeader
+writes it, and the decoders accept both forms, negotiating the version of the header
+
+value layout by valType:
+	INT32:		le int32
+	STRING:		utf8 bytes
+	BYTES:		raw bytes
+	INT64:		le int64
+	UINT64:		le uint64
+	BOOL:		byte[1], 0 or 1
+	DECIMAL:	decimal string, [-]digits[.digits]
+	CODEC:		nested serialization, without header
+	LIST:		itemCount + (valType + valLen + val)*
 */
 
 package sdk
@@ -26,6 +40,7 @@ import (
 	"bytes"
 	"encoding/base64"
 	"errors"
+	"fmt"
 	"strconv"
 
 	//"github.com/TKOTKCh/contract-sdk-go-wasm/sdk/convert"
@@ -38,6 +53,20 @@ var ecVersion = []byte{118, 49, 46, 48}   // "v1.0"
 var ecReserved = []byte{255, 255, 255, 255, 255, 255, 255, 255}
 
 // var ecHeader = []byte{99, 109, 101, 99, 118, 49, 46, 48, 255, 255, 255, 255, 255, 255, 255, 255}
+
+// EasyVersion easycodec version of the header
+type EasyVersion string
+
+const (
+	EasyVersion_V1_0 EasyVersion = "v1.0"
+)
+
+// easyVersions the header versions that can be decoded, headerless data has the v1.0 layout.
+// A v1.1 layout adds its version here and switches on the negotiated version in the decoders
+var easyVersions = map[EasyVersion]bool{
+	EasyVersion_V1_0: true,
+}
+
 type EasyKeyType int32
 type EasyValueType int32
 
@@ -45,75 +74,367 @@ const (
 	EasyKeyType_SYSTEM EasyKeyType = 0
 	EasyKeyType_USER   EasyKeyType = 1
 
-	EasyValueType_INT32  EasyValueType = 0
-	EasyValueType_STRING EasyValueType = 1
-	EasyValueType_BYTES  EasyValueType = 2
+	EasyValueType_INT32   EasyValueType = 0
+	EasyValueType_STRING  EasyValueType = 1
+	EasyValueType_BYTES   EasyValueType = 2
+	EasyValueType_INT64   EasyValueType = 3
+	EasyValueType_UINT64  EasyValueType = 4
+	EasyValueType_BOOL    EasyValueType = 5
+	EasyValueType_DECIMAL EasyValueType = 6
+	EasyValueType_CODEC   EasyValueType = 7
+	EasyValueType_LIST    EasyValueType = 8
 
 	MAX_KEY_COUNT    = 128
+	MAX_DEPTH        = 8
 	MAX_KEY_LEN      = 64
 	MAX_VALUE_LEN    = 1024 * 1024
 	MIN_LEN          = 20
 	EC_MAGIC_NUM_LEN = 4
 	EC_VERSION_LEN   = 4
 	EC_RESERVED_LEN  = 8
+	EC_HEADER_LEN    = EC_MAGIC_NUM_LEN + EC_VERSION_LEN + EC_RESERVED_LEN
+)
+
+// EasyDupPolicy what adding a key already present with the same key type does
+type EasyDupPolicy int32
+
+const (
+	// EasyDupPolicy_KEEP_ALL append the item, GetItem returns the first one and GetAll all of them
+	EasyDupPolicy_KEEP_ALL EasyDupPolicy = 0
+	// EasyDupPolicy_OVERWRITE replace the value of the existing item, keeping its position
+	EasyDupPolicy_OVERWRITE EasyDupPolicy = 1
+	// EasyDupPolicy_REJECT drop the item, the first rejected key is returned by Err
+	EasyDupPolicy_REJECT EasyDupPolicy = 2
 )
 
 type EasyCodec struct {
-	items []*EasyCodecItem
+	items  []*EasyCodecItem
+	policy EasyDupPolicy
+	// index the items by key type and key in order, nil unless EnableIndex
+	index map[easyIndexKey][]*EasyCodecItem
+	err   error
+}
+
+type easyIndexKey struct {
+	keyType EasyKeyType
+	key     string
 }
 
 func NewEasyCodec() *EasyCodec {
 	items := make([]*EasyCodecItem, 0)
-	return &EasyCodec{items}
+	return &EasyCodec{items: items}
 }
 
 func NewEasyCodecWithMap(value map[string][]byte) *EasyCodec {
 	items := ParamsMapToEasyCodecItem(value)
-	return &EasyCodec{items}
+	return &EasyCodec{items: items}
 }
 
 func NewEasyCodecWithBytes(value []byte) *EasyCodec {
-	return &EasyCodec{EasyUnmarshal(value)}
+	return &EasyCodec{items: EasyUnmarshal(value)}
 }
 
 func NewEasyCodecWithItems(items []*EasyCodecItem) *EasyCodec {
 	return &EasyCodec{items: items}
 }
 
+// SetDupPolicy set what the following Add* do with a key already present, EasyDupPolicy_KEEP_ALL by default
+func (e *EasyCodec) SetDupPolicy(policy EasyDupPolicy) {
+	e.policy = policy
+}
+
+// EnableIndex index the items by key type and key, lookups and duplicate checks no longer scan the
+// items. The index follows the methods of EasyCodec, not the changes made through GetItems
+func (e *EasyCodec) EnableIndex() {
+	e.index = make(map[easyIndexKey][]*EasyCodecItem, len(e.items))
+	for _, item := range e.items {
+		k := easyIndexKey{item.KeyType, item.Key}
+		e.index[k] = append(e.index[k], item)
+	}
+}
+
+// Err return the first key rejected by EasyDupPolicy_REJECT, wrapping ErrDuplicateKey
+func (e *EasyCodec) Err() error {
+	return e.err
+}
+
+// find return the items with key type and key, in order
+func (e *EasyCodec) find(keyType EasyKeyType, key string) []*EasyCodecItem {
+	if e.index != nil {
+		return e.index[easyIndexKey{keyType, key}]
+	}
+	var found []*EasyCodecItem
+	for _, item := range e.items {
+		if item.Key == key && item.KeyType == keyType {
+			found = append(found, item)
+		}
+	}
+	return found
+}
+
+func (e *EasyCodec) first(keyType EasyKeyType, key string) *EasyCodecItem {
+	if e.index != nil {
+		if found := e.index[easyIndexKey{keyType, key}]; len(found) > 0 {
+			return found[0]
+		}
+		return nil
+	}
+	for _, item := range e.items {
+		if item.Key == key && item.KeyType == keyType {
+			return item
+		}
+	}
+	return nil
+}
+
+func (e *EasyCodec) append(item *EasyCodecItem) {
+	e.items = append(e.items, item)
+	if e.index != nil {
+		k := easyIndexKey{item.KeyType, item.Key}
+		e.index[k] = append(e.index[k], item)
+	}
+}
+
+// add append item following the duplicate policy
+func (e *EasyCodec) add(item *EasyCodecItem) {
+	if e.policy != EasyDupPolicy_KEEP_ALL {
+		if existing := e.first(item.KeyType, item.Key); existing != nil {
+			if e.policy == EasyDupPolicy_OVERWRITE {
+				existing.ValueType, existing.Value = item.ValueType, item.Value
+			} else if e.err == nil {
+				e.err = fmt.Errorf("key %q: %w", item.Key, ErrDuplicateKey)
+			}
+			return
+		}
+	}
+	e.append(item)
+}
+
+// set replace the value of the first item with the key type and key, removing the other ones,
+// or append item
+func (e *EasyCodec) set(item *EasyCodecItem) {
+	found := e.find(item.KeyType, item.Key)
+	if len(found) == 0 {
+		e.append(item)
+		return
+	}
+	kept := found[0]
+	kept.ValueType, kept.Value = item.ValueType, item.Value
+	if len(found) > 1 {
+		e.removeIf(func(it *EasyCodecItem) bool {
+			return it != kept && it.KeyType == kept.KeyType && it.Key == kept.Key
+		})
+	}
+}
+
+func (e *EasyCodec) removeIf(match func(item *EasyCodecItem) bool) {
+	kept := e.items[:0]
+	for _, item := range e.items {
+		if !match(item) {
+			kept = append(kept, item)
+		}
+	}
+	for i := len(kept); i < len(e.items); i++ {
+		e.items[i] = nil
+	}
+	e.items = kept
+	if e.index != nil {
+		e.EnableIndex()
+	}
+}
+
 func (e *EasyCodec) AddInt32(key string, value int32) {
-	e.items = append(e.items, newEasyCodecItemWithInt32(key, value))
+	e.add(newEasyCodecItemWithInt32(key, value))
 }
 
 func (e *EasyCodec) AddString(key string, value string) {
-	e.items = append(e.items, newEasyCodecItemWithString(key, value))
+	e.add(newEasyCodecItemWithString(key, value))
 }
 
 func (e *EasyCodec) AddBytes(key string, value []byte) {
-	e.items = append(e.items, newEasyCodecItemWithBytes(key, value))
+	e.add(newEasyCodecItemWithBytes(key, value))
+}
+
+func (e *EasyCodec) AddInt64(key string, value int64) {
+	e.add(newEasyCodecItem(EasyKeyType_USER, key, EasyValueType_INT64, value))
+}
+
+func (e *EasyCodec) AddUint64(key string, value uint64) {
+	e.add(newEasyCodecItem(EasyKeyType_USER, key, EasyValueType_UINT64, value))
+}
+
+func (e *EasyCodec) AddBool(key string, value bool) {
+	e.add(newEasyCodecItem(EasyKeyType_USER, key, EasyValueType_BOOL, value))
+}
+
+// AddDecimal add a decimal, an invalid one is ErrCodec, see ParseEasyDecimal
+func (e *EasyCodec) AddDecimal(key string, value EasyDecimal) error {
+	if _, err := ParseEasyDecimal(string(value)); err != nil {
+		return err
+	}
+	e.add(newEasyCodecItem(EasyKeyType_USER, key, EasyValueType_DECIMAL, value))
+	return nil
+}
+
+// AddCodec add a nested EasyCodec. A nil value, e itself, or a value nesting over MAX_DEPTH
+// codecs and lists is ErrCodec, as EasyUnmarshal could not decode it
+func (e *EasyCodec) AddCodec(key string, value *EasyCodec) error {
+	if err := e.checkNested(EasyValueType_CODEC, value, 0); err != nil {
+		return err
+	}
+	e.add(newEasyCodecItem(EasyKeyType_USER, key, EasyValueType_CODEC, value))
+	return nil
+}
+
+// AddList add a list of values, element types follow EasyValueOf. An unsupported element,
+// an invalid decimal or a nesting as in AddCodec is ErrCodec
+func (e *EasyCodec) AddList(key string, values []interface{}) error {
+	if err := e.checkNested(EasyValueType_LIST, values, 0); err != nil {
+		return err
+	}
+	e.add(newEasyCodecItem(EasyKeyType_USER, key, EasyValueType_LIST, values))
+	return nil
+}
+
+// checkNested check a CODEC or LIST value of an item at depth: it nests at most as deep as
+// EasyUnmarshal decodes, does not hold e, and its list elements are supported values
+func (e *EasyCodec) checkNested(valueType EasyValueType, value interface{}, depth int) error {
+	switch valueType {
+	case EasyValueType_CODEC:
+		codec, _ := value.(*EasyCodec)
+		if codec == nil {
+			return fmt.Errorf("nil codec: %w", ErrCodec)
+		}
+		if codec == e {
+			return fmt.Errorf("codec nested in itself: %w", ErrCodec)
+		}
+		if depth >= MAX_DEPTH {
+			return errNesting()
+		}
+		for _, item := range codec.items {
+			if err := e.checkNested(item.ValueType, item.Value, depth+1); err != nil {
+				return err
+			}
+		}
+	case EasyValueType_LIST:
+		if depth >= MAX_DEPTH {
+			return errNesting()
+		}
+		for _, v := range value.([]interface{}) {
+			elemType, ok := EasyValueOf(v)
+			if !ok {
+				return fmt.Errorf("unsupported list value type %T: %w", v, ErrCodec)
+			}
+			if d, isDecimal := v.(EasyDecimal); isDecimal {
+				if _, err := ParseEasyDecimal(string(d)); err != nil {
+					return err
+				}
+			}
+			if err := e.checkNested(elemType, v, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// errNesting the error of values nested over MAX_DEPTH codecs and lists
+func errNesting() error {
+	return fmt.Errorf("nesting over depth %d: %w", MAX_DEPTH, ErrCodec)
 }
 
 func (e *EasyCodec) AddMap(value map[string][]byte) {
 	items := ParamsMapToEasyCodecItem(value)
-	e.items = append(e.items, items...)
+	for _, item := range items {
+		e.add(item)
+	}
 }
 func (e *EasyCodec) AddValue(keyType EasyKeyType, key string, valueType EasyValueType, value interface{}) {
-	e.items = append(e.items, newEasyCodecItem(keyType, key, valueType, value))
+	e.add(newEasyCodecItem(keyType, key, valueType, value))
 }
 
 func (e *EasyCodec) AddItem(item *EasyCodecItem) {
-	e.items = append(e.items, item)
+	e.add(item)
+}
+
+// SetInt32 upsert: key holds only value afterwards, whatever the duplicate policy
+func (e *EasyCodec) SetInt32(key string, value int32) {
+	e.set(newEasyCodecItemWithInt32(key, value))
+}
+
+// SetString upsert a string
+func (e *EasyCodec) SetString(key string, value string) {
+	e.set(newEasyCodecItemWithString(key, value))
+}
+
+// SetBytes upsert bytes
+func (e *EasyCodec) SetBytes(key string, value []byte) {
+	e.set(newEasyCodecItemWithBytes(key, value))
+}
+
+// SetInt64 upsert an int64
+func (e *EasyCodec) SetInt64(key string, value int64) {
+	e.set(newEasyCodecItem(EasyKeyType_USER, key, EasyValueType_INT64, value))
+}
+
+// SetUint64 upsert an uint64
+func (e *EasyCodec) SetUint64(key string, value uint64) {
+	e.set(newEasyCodecItem(EasyKeyType_USER, key, EasyValueType_UINT64, value))
+}
+
+// SetBool upsert a bool
+func (e *EasyCodec) SetBool(key string, value bool) {
+	e.set(newEasyCodecItem(EasyKeyType_USER, key, EasyValueType_BOOL, value))
+}
+
+// SetDecimal upsert a decimal, an invalid one is ErrCodec, see ParseEasyDecimal
+func (e *EasyCodec) SetDecimal(key string, value EasyDecimal) error {
+	if _, err := ParseEasyDecimal(string(value)); err != nil {
+		return err
+	}
+	e.set(newEasyCodecItem(EasyKeyType_USER, key, EasyValueType_DECIMAL, value))
+	return nil
+}
+
+// SetCodec upsert a nested EasyCodec, the errors are the ones of AddCodec
+func (e *EasyCodec) SetCodec(key string, value *EasyCodec) error {
+	if err := e.checkNested(EasyValueType_CODEC, value, 0); err != nil {
+		return err
+	}
+	e.set(newEasyCodecItem(EasyKeyType_USER, key, EasyValueType_CODEC, value))
+	return nil
 }
 
+// SetList upsert a list of values, the errors are the ones of AddList
+func (e *EasyCodec) SetList(key string, values []interface{}) error {
+	if err := e.checkNested(EasyValueType_LIST, values, 0); err != nil {
+		return err
+	}
+	e.set(newEasyCodecItem(EasyKeyType_USER, key, EasyValueType_LIST, values))
+	return nil
+}
+
+// SetValue upsert a value with its key type
+func (e *EasyCodec) SetValue(keyType EasyKeyType, key string, valueType EasyValueType, value interface{}) {
+	e.set(newEasyCodecItem(keyType, key, valueType, value))
+}
+
+// RemoveKey remove all the user items with key, as GetAll returns them
 func (e *EasyCodec) RemoveKey(key string) {
-	for i, item := range e.items {
-		if item.Key == key {
-			e.items = append(e.items[:i], e.items[i+1:]...)
-			return
-		}
+	e.RemoveValue(EasyKeyType_USER, key)
+}
+
+// RemoveValue remove all the items with key type and key
+func (e *EasyCodec) RemoveValue(keyType EasyKeyType, key string) {
+	if e.index != nil && e.first(keyType, key) == nil {
+		return
 	}
+	e.removeIf(func(item *EasyCodecItem) bool {
+		return item.KeyType == keyType && item.Key == key
+	})
 }
 
-// toJson simple json, no nesting, rule: int32->strconv.itoa(val) []byte->string([]byte)
+// toJson simple json, lossy, see MarshalJSON for a typed round trip
 func (e *EasyCodec) ToJson() string {
 	return EasyCodecItemToJsonStr(e.items)
 }
@@ -127,19 +448,21 @@ func (e *EasyCodec) GetItems() []*EasyCodecItem {
 }
 
 func (e *EasyCodec) GetItem(key string, keyType EasyKeyType) (*EasyCodecItem, error) {
-	for _, item := range e.items {
-		if item.Key == key && item.KeyType == keyType {
-			return item, nil
-		}
+	if item := e.first(keyType, key); item != nil {
+		return item, nil
 	}
 	return nil, errors.New("not found key with keyType")
 }
 
+// GetAll get all the user items with key, in order, see EasyDupPolicy_KEEP_ALL
+func (e *EasyCodec) GetAll(key string) []*EasyCodecItem {
+	found := e.find(EasyKeyType_USER, key)
+	return append([]*EasyCodecItem(nil), found...)
+}
+
 func (e *EasyCodec) GetValue(key string, keyType EasyKeyType) (interface{}, error) {
-	for _, item := range e.items {
-		if item.Key == key && item.KeyType == keyType {
-			return item.Value, nil
-		}
+	if item := e.first(keyType, key); item != nil {
+		return item.Value, nil
 	}
 	return nil, errors.New("not found key with keyType")
 }
@@ -168,11 +491,133 @@ func (e *EasyCodec) GetBytes(key string) ([]byte, error) {
 	return nil, errors.New("not found key or value type not bytes")
 }
 
+func (e *EasyCodec) GetInt64(key string) (int64, error) {
+	item, err := e.GetItem(key, EasyKeyType_USER)
+	if err == nil && item.ValueType == EasyValueType_INT64 {
+		return item.Value.(int64), nil
+	}
+	return 0, errors.New("not found key or value type not int64")
+}
+
+func (e *EasyCodec) GetUint64(key string) (uint64, error) {
+	item, err := e.GetItem(key, EasyKeyType_USER)
+	if err == nil && item.ValueType == EasyValueType_UINT64 {
+		return item.Value.(uint64), nil
+	}
+	return 0, errors.New("not found key or value type not uint64")
+}
+
+func (e *EasyCodec) GetBool(key string) (bool, error) {
+	item, err := e.GetItem(key, EasyKeyType_USER)
+	if err == nil && item.ValueType == EasyValueType_BOOL {
+		return item.Value.(bool), nil
+	}
+	return false, errors.New("not found key or value type not bool")
+}
+
+func (e *EasyCodec) GetDecimal(key string) (EasyDecimal, error) {
+	item, err := e.GetItem(key, EasyKeyType_USER)
+	if err == nil && item.ValueType == EasyValueType_DECIMAL {
+		return item.Value.(EasyDecimal), nil
+	}
+	return "", errors.New("not found key or value type not decimal")
+}
+
+func (e *EasyCodec) GetCodec(key string) (*EasyCodec, error) {
+	item, err := e.GetItem(key, EasyKeyType_USER)
+	if err == nil && item.ValueType == EasyValueType_CODEC {
+		return item.Value.(*EasyCodec), nil
+	}
+	return nil, errors.New("not found key or value type not codec")
+}
+
+func (e *EasyCodec) GetList(key string) ([]interface{}, error) {
+	item, err := e.GetItem(key, EasyKeyType_USER)
+	if err == nil && item.ValueType == EasyValueType_LIST {
+		return item.Value.([]interface{}), nil
+	}
+	return nil, errors.New("not found key or value type not list")
+}
+
+// Marshal serialize the items without header. AddCodec and AddList reject the values nesting over
+// MAX_DEPTH; items nesting deeper anyway, through AddValue or a nested codec changed afterwards,
+// or holding a cycle, panic with an error wrapping ErrCodec
 func (e *EasyCodec) Marshal() []byte {
 	return EasyMarshal(e.items)
 }
 
-// EasyCodecItem ValueType only support int32/string/[]byte
+// MarshalWithHeader serialize with the cmec header, as expected by contracts of other languages
+func (e *EasyCodec) MarshalWithHeader() []byte {
+	return EasyMarshalWithHeader(e.items)
+}
+
+// EasyDecimal decimal number without float rounding, as "-12.340"
+type EasyDecimal string
+
+// ParseEasyDecimal verify s is [-]digits[.digits] without leading zeros, "0.5" but not "00.5",
+// otherwise ErrCodec. Trailing zeros of the fraction are kept as precision
+func ParseEasyDecimal(s string) (EasyDecimal, error) {
+	digits := strings.TrimPrefix(s, "-")
+	intPart, fracPart, hasFrac := strings.Cut(digits, ".")
+	if !isDigits(intPart) || (hasFrac && !isDigits(fracPart)) || (len(intPart) > 1 && intPart[0] == '0') {
+		return "", fmt.Errorf("invalid decimal %q: %w", s, ErrCodec)
+	}
+	return EasyDecimal(s), nil
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// EasyValueOf return the EasyValueType of a go value: int32, string, []byte, int64, uint64,
+// bool, EasyDecimal, *EasyCodec and []interface{} of those
+func EasyValueOf(value interface{}) (EasyValueType, bool) {
+	return easyValueOf(value, 0)
+}
+
+// easyValueOf EasyValueOf of a value nested in depth lists, lists over MAX_DEPTH are not supported
+// so that a list holding itself ends
+func easyValueOf(value interface{}, depth int) (EasyValueType, bool) {
+	switch v := value.(type) {
+	case int32:
+		return EasyValueType_INT32, true
+	case string:
+		return EasyValueType_STRING, true
+	case []byte:
+		return EasyValueType_BYTES, true
+	case int64:
+		return EasyValueType_INT64, true
+	case uint64:
+		return EasyValueType_UINT64, true
+	case bool:
+		return EasyValueType_BOOL, true
+	case EasyDecimal:
+		return EasyValueType_DECIMAL, true
+	case *EasyCodec:
+		return EasyValueType_CODEC, v != nil
+	case []interface{}:
+		if depth >= MAX_DEPTH {
+			return EasyValueType_LIST, false
+		}
+		for _, elem := range v {
+			if _, ok := easyValueOf(elem, depth+1); !ok {
+				return EasyValueType_LIST, false
+			}
+		}
+		return EasyValueType_LIST, true
+	}
+	return 0, false
+}
+
+// EasyCodecItem ValueType support int32/string/[]byte/int64/uint64/bool/EasyDecimal/*EasyCodec/[]interface{}
 type EasyCodecItem struct {
 	KeyType EasyKeyType
 	Key     string
@@ -235,12 +680,22 @@ func EasyCodecItemToParamsMap(items []*EasyCodecItem) map[string][]byte {
 		case EasyValueType_STRING:
 			params[item.Key] = []byte(item.Value.(string))
 			break
+		case EasyValueType_INT64:
+			params[item.Key] = []byte(strconv.FormatInt(item.Value.(int64), 10))
+		case EasyValueType_UINT64:
+			params[item.Key] = []byte(strconv.FormatUint(item.Value.(uint64), 10))
+		case EasyValueType_BOOL:
+			params[item.Key] = []byte(strconv.FormatBool(item.Value.(bool)))
+		case EasyValueType_DECIMAL:
+			params[item.Key] = []byte(item.Value.(EasyDecimal))
+		case EasyValueType_CODEC, EasyValueType_LIST:
+			params[item.Key] = marshalValue(item.ValueType, item.Value, 0)
 		}
 	}
 	return params
 }
 
-// EasyCodecItemToJsonStr simple json, no nesting, rule: int32->strconv.itoa(val) []byte->string([]byte)
+// EasyCodecItemToJsonStr simple json, rule: int32->strconv.itoa(val) []byte->base64, codec->object, list->array
 func EasyCodecItemToJsonStr(items []*EasyCodecItem) string {
 	if items == nil {
 		return "{}"
@@ -249,28 +704,9 @@ func EasyCodecItemToJsonStr(items []*EasyCodecItem) string {
 	build.WriteString("{")
 	total := len(items)
 	for i, item := range items {
-		key := item.Key
-		build.WriteString("\"")
-		build.WriteString(key)
-		build.WriteString("\":")
-		var val string
-		switch item.ValueType {
-		case EasyValueType_INT32:
-
-			val = strconv.FormatInt(int64(item.Value.(int32)), 10)
-			build.WriteString(val)
-		case EasyValueType_STRING:
-			val = item.Value.(string)
-			val = strings.ReplaceAll(val, "\"", "\\\"")
-			build.WriteString("\"")
-			build.WriteString(val)
-			build.WriteString("\"")
-		case EasyValueType_BYTES:
-			val = base64.StdEncoding.EncodeToString(item.Value.([]byte))
-			build.WriteString("\"")
-			build.WriteString(val)
-			build.WriteString("\"")
-		}
+		writeJsonString(&build, item.Key)
+		build.WriteString(":")
+		writeJsonValue(&build, item.ValueType, item.Value)
 		if i != total-1 {
 			build.WriteString(",")
 		}
@@ -279,6 +715,45 @@ func EasyCodecItemToJsonStr(items []*EasyCodecItem) string {
 	return build.String()
 }
 
+func writeJsonValue(build *strings.Builder, valueType EasyValueType, value interface{}) {
+	var val string
+	switch valueType {
+	case EasyValueType_INT32:
+
+		val = strconv.FormatInt(int64(value.(int32)), 10)
+		build.WriteString(val)
+	case EasyValueType_STRING:
+		writeJsonString(build, value.(string))
+	case EasyValueType_BYTES:
+		val = base64.StdEncoding.EncodeToString(value.([]byte))
+		build.WriteString("\"")
+		build.WriteString(val)
+		build.WriteString("\"")
+	case EasyValueType_INT64:
+		build.WriteString(strconv.FormatInt(value.(int64), 10))
+	case EasyValueType_UINT64:
+		build.WriteString(strconv.FormatUint(value.(uint64), 10))
+	case EasyValueType_BOOL:
+		build.WriteString(strconv.FormatBool(value.(bool)))
+	case EasyValueType_DECIMAL:
+		writeJsonString(build, string(value.(EasyDecimal)))
+	case EasyValueType_CODEC:
+		build.WriteString(value.(*EasyCodec).ToJson())
+	case EasyValueType_LIST:
+		build.WriteString("[")
+		for i, elem := range value.([]interface{}) {
+			if i > 0 {
+				build.WriteString(",")
+			}
+			elemType, _ := EasyValueOf(elem)
+			writeJsonValue(build, elemType, elem)
+		}
+		build.WriteString("]")
+	default:
+		build.WriteString("null")
+	}
+}
+
 // GetValue get value from item
 func (e *EasyCodecItem) GetValue(key string, keyType EasyKeyType) (interface{}, bool) {
 	if e.KeyType == keyType && e.Key == key {
@@ -287,55 +762,129 @@ func (e *EasyCodecItem) GetValue(key string, keyType EasyKeyType) (interface{},
 	return "", false
 }
 
-// EasyMarshal serialize item into binary
+// EasyMarshal serialize item into binary, without header
 func EasyMarshal(items []*EasyCodecItem) []byte {
+	return easyMarshal(items, false, 0)
+}
+
+// EasyMarshalWithHeader serialize item into binary, starting with the magicNum + ecVersion + reserved
+// header of the current version. EasyUnmarshal decodes both forms to the same items, nested codecs
+// are always serialized without header
+func EasyMarshalWithHeader(items []*EasyCodecItem) []byte {
+	return easyMarshal(items, true, 0)
+}
+
+// easyMarshal serialize items nested in depth codecs and lists, values nested over MAX_DEPTH
+// panic, see Marshal
+func easyMarshal(items []*EasyCodecItem, header bool, depth int) []byte {
 	buf := new(bytes.Buffer)
 	uint32DataBytes := make([]byte, 4)
 
-	//buf.Write(ecMagicNum)
-	//buf.Write(ecVersion)
-	//buf.Write(ecReserved)
-
-	binaryUint32Marshal(buf, uint32(len(items)), uint32DataBytes)
+	if header {
+		buf.Write(ecMagicNum)
+		buf.Write(ecVersion)
+		buf.Write(ecReserved)
+	}
 
+	// items with an unknown key or value type are skipped, and not counted
+	valid := make([]*EasyCodecItem, 0, len(items))
 	for _, item := range items {
-
 		if item.KeyType != EasyKeyType_SYSTEM && item.KeyType != EasyKeyType_USER {
 			continue
 		}
+		if item.ValueType < EasyValueType_INT32 || item.ValueType > EasyValueType_LIST {
+			continue
+		}
+		valid = append(valid, item)
+	}
 
+	binaryUint32Marshal(buf, uint32(len(valid)), uint32DataBytes)
+
+	for _, item := range valid {
 		binaryUint32Marshal(buf, uint32(item.KeyType), uint32DataBytes)
 		binaryUint32Marshal(buf, uint32(len(item.Key)), uint32DataBytes)
 		buf.Write([]byte(item.Key))
 
-		switch item.ValueType {
-
-		case EasyValueType_INT32:
-
-			binaryUint32Marshal(buf, uint32(item.ValueType), uint32DataBytes)
-			binaryUint32Marshal(buf, uint32(4), uint32DataBytes)
-			binaryUint32Marshal(buf, uint32(item.Value.(int32)), uint32DataBytes)
-
-		case EasyValueType_STRING:
-
-			binaryUint32Marshal(buf, uint32(item.ValueType), uint32DataBytes)
-			binaryUint32Marshal(buf, uint32(len(item.Value.(string))), uint32DataBytes)
-			buf.Write([]byte(item.Value.(string)))
+		writeValue(buf, item.ValueType, item.Value, uint32DataBytes, depth)
+	}
 
-		case EasyValueType_BYTES:
+	return buf.Bytes()
+}
 
-			binaryUint32Marshal(buf, uint32(item.ValueType), uint32DataBytes)
-			binaryUint32Marshal(buf, uint32(len(item.Value.([]byte))), uint32DataBytes)
-			buf.Write(item.Value.([]byte))
+// writeValue write valType + valLen + val
+func writeValue(buf *bytes.Buffer, valueType EasyValueType, value interface{}, uint32DataBytes []byte, depth int) {
+	binaryUint32Marshal(buf, uint32(valueType), uint32DataBytes)
+	switch valueType {
+	case EasyValueType_INT32:
+		binaryUint32Marshal(buf, uint32(4), uint32DataBytes)
+		binaryUint32Marshal(buf, uint32(value.(int32)), uint32DataBytes)
+	case EasyValueType_STRING:
+		binaryUint32Marshal(buf, uint32(len(value.(string))), uint32DataBytes)
+		buf.WriteString(value.(string))
+	case EasyValueType_BYTES:
+		binaryUint32Marshal(buf, uint32(len(value.([]byte))), uint32DataBytes)
+		buf.Write(value.([]byte))
+	default:
+		val := marshalValue(valueType, value, depth)
+		binaryUint32Marshal(buf, uint32(len(val)), uint32DataBytes)
+		buf.Write(val)
+	}
+}
 
+// marshalValue serialize the val of the value types added after v1.0
+func marshalValue(valueType EasyValueType, value interface{}, depth int) []byte {
+	switch valueType {
+	case EasyValueType_CODEC, EasyValueType_LIST:
+		if depth >= MAX_DEPTH {
+			panic(errNesting())
 		}
 	}
+	switch valueType {
+	case EasyValueType_INT64:
+		return binaryUint64Marshal(uint64(value.(int64)))
+	case EasyValueType_UINT64:
+		return binaryUint64Marshal(value.(uint64))
+	case EasyValueType_BOOL:
+		if value.(bool) {
+			return []byte{1}
+		}
+		return []byte{0}
+	case EasyValueType_DECIMAL:
+		return []byte(value.(EasyDecimal))
+	case EasyValueType_CODEC:
+		return easyMarshal(value.(*EasyCodec).items, false, depth+1)
+	case EasyValueType_LIST:
+		values := value.([]interface{})
+		buf := new(bytes.Buffer)
+		uint32DataBytes := make([]byte, 4)
+		binaryUint32Marshal(buf, uint32(len(values)), uint32DataBytes)
+		for _, v := range values {
+			elemType, _ := EasyValueOf(v)
+			writeValue(buf, elemType, v, uint32DataBytes, depth+1)
+		}
+		return buf.Bytes()
+	}
+	return nil
+}
 
-	return buf.Bytes()
+// ParseEasyHeader split data into the version of its header and the serialized items. Headerless
+// data, as written by EasyMarshal, gives an empty version; no item count can start with the magicNum
+// since it is over MAX_KEY_COUNT. An unknown version or a bad header is an error wrapping ErrCodec
+func ParseEasyHeader(data []byte) (EasyVersion, []byte, error) {
+	r := &easyReader{data: data}
+	version, err := r.header()
+	if err != nil {
+		return "", nil, err
+	}
+	return version, data[r.off:], nil
 }
 
 // EasyUnmarshal Deserialized from binary to item
 func EasyUnmarshal(data []byte) []*EasyCodecItem {
+	return easyUnmarshal(data, 0)
+}
+
+func easyUnmarshal(data []byte, depth int) []*EasyCodecItem {
 	var (
 		items         []*EasyCodecItem
 		easyKeyType   EasyKeyType
@@ -345,38 +894,30 @@ func EasyUnmarshal(data []byte) []*EasyCodecItem {
 		valueLength   int32
 	)
 
-	if len(data) <= MIN_LEN {
+	// the header may be absent, an unsupported one gives no item
+	_, body, err := ParseEasyHeader(data)
+	if err != nil || len(body) < 4 {
 		return items
 	}
-	buf := bytes.NewBuffer(data)
+	buf := bytes.NewBuffer(body)
 	uint32DataBytes := make([]byte, 4)
 
-	var count uint32 = 0
-	magicNum := make([]byte, EC_MAGIC_NUM_LEN)
-	buf.Read(magicNum)
-	if bytes.Equal(magicNum, ecMagicNum) {
-		version := make([]byte, EC_VERSION_LEN)
-		reserved := make([]byte, EC_RESERVED_LEN)
-		buf.Read(version)
-		buf.Read(reserved)
-		if !(bytes.Equal(magicNum, ecMagicNum) && bytes.Equal(version, ecVersion) && bytes.Equal(reserved, ecReserved)) {
-			return items
-		}
-		count = binaryUint32Unmarshal(buf, uint32DataBytes)
-	} else {
-		count = binaryUint32Unmarshal(bytes.NewBuffer(magicNum), uint32DataBytes)
-	}
-
+	count := binaryUint32Unmarshal(buf, uint32DataBytes)
 	if count > MAX_KEY_COUNT {
 		return items
 	}
 
 	for i := 0; i < int(count); i++ {
+		// a truncated item ends the result, keyType + keyLen + valType + valLen at least
+		if buf.Len() < 16 {
+			return items
+		}
+
 		// Key Part
 		easyKeyType = EasyKeyType(binaryUint32Unmarshal(buf, uint32DataBytes))
 
 		keyLength = int32(binaryUint32Unmarshal(buf, uint32DataBytes))
-		if keyLength > MAX_KEY_LEN {
+		if keyLength < 0 || keyLength > MAX_KEY_LEN || int(keyLength)+8 > buf.Len() {
 			return items
 		}
 		keyContent = make([]byte, keyLength)
@@ -386,7 +927,7 @@ func EasyUnmarshal(data []byte) []*EasyCodecItem {
 		easyValueType = EasyValueType(binaryUint32Unmarshal(buf, uint32DataBytes))
 
 		valueLength = int32(binaryUint32Unmarshal(buf, uint32DataBytes))
-		if valueLength > MAX_VALUE_LEN {
+		if valueLength < 0 || valueLength > MAX_VALUE_LEN || int(valueLength) > buf.Len() {
 			return items
 		}
 
@@ -404,6 +945,14 @@ func EasyUnmarshal(data []byte) []*EasyCodecItem {
 			valueContent := make([]byte, valueLength)
 			buf.Read(valueContent)
 			easyCodecItem.Value = valueContent
+		default:
+			valueContent := make([]byte, valueLength)
+			buf.Read(valueContent)
+			value, ok := unmarshalValue(easyValueType, valueContent, depth, false)
+			if !ok {
+				return items
+			}
+			easyCodecItem.Value = value
 		}
 
 		easyCodecItem.KeyType = easyKeyType
@@ -414,6 +963,224 @@ func EasyUnmarshal(data []byte) []*EasyCodecItem {
 	return items
 }
 
+// EasyUnmarshalStrict Deserialized from binary to item, unlike EasyUnmarshal any violation of the
+// format is an error wrapping ErrCodec instead of a partial result: bad header, item count over
+// MAX_KEY_COUNT, key over MAX_KEY_LEN, value over MAX_VALUE_LEN, unknown key or value type,
+// truncated input and trailing bytes. It never panics, whatever data is
+func EasyUnmarshalStrict(data []byte) ([]*EasyCodecItem, error) {
+	return easyUnmarshalStrict(data, 0)
+}
+
+func easyUnmarshalStrict(data []byte, depth int) ([]*EasyCodecItem, error) {
+	r := &easyReader{data: data}
+	if _, err := r.header(); err != nil {
+		return nil, err
+	}
+
+	count, err := r.uint32()
+	if err != nil {
+		return nil, err
+	}
+	if count > MAX_KEY_COUNT {
+		return nil, r.errorf("item count %d over limit %d", count, MAX_KEY_COUNT)
+	}
+	// an item takes 16 bytes at least, the count alone does not size the allocation
+	items := make([]*EasyCodecItem, 0, minInt(int(count), (len(data)-r.off)/16))
+	for i := uint32(0); i < count; i++ {
+		keyType, err := r.uint32()
+		if err != nil {
+			return nil, err
+		}
+		if EasyKeyType(keyType) != EasyKeyType_SYSTEM && EasyKeyType(keyType) != EasyKeyType_USER {
+			return nil, r.errorf("unknown key type %d", keyType)
+		}
+		keyLength, err := r.uint32()
+		if err != nil {
+			return nil, err
+		}
+		if keyLength > MAX_KEY_LEN {
+			return nil, r.errorf("key length %d over limit %d", keyLength, MAX_KEY_LEN)
+		}
+		key, err := r.next(int(keyLength))
+		if err != nil {
+			return nil, err
+		}
+
+		valueType, err := r.uint32()
+		if err != nil {
+			return nil, err
+		}
+		valueLength, err := r.uint32()
+		if err != nil {
+			return nil, err
+		}
+		if valueLength > MAX_VALUE_LEN {
+			return nil, r.errorf("value length %d of key %q over limit %d", valueLength, key, MAX_VALUE_LEN)
+		}
+		val, err := r.next(int(valueLength))
+		if err != nil {
+			return nil, err
+		}
+		value, ok := unmarshalValue(EasyValueType(valueType), val, depth, true)
+		if !ok {
+			return nil, r.errorf("invalid value of key %q with value type %d", key, valueType)
+		}
+		items = append(items, &EasyCodecItem{
+			KeyType:   EasyKeyType(keyType),
+			Key:       string(key),
+			ValueType: EasyValueType(valueType),
+			Value:     value,
+		})
+	}
+	if r.off != len(data) {
+		return nil, r.errorf("%d trailing bytes", len(data)-r.off)
+	}
+	return items, nil
+}
+
+// easyReader bounds checked reader of serialized items
+type easyReader struct {
+	data []byte
+	off  int
+}
+
+func (r *easyReader) next(n int) ([]byte, error) {
+	if n < 0 || n > len(r.data)-r.off {
+		return nil, r.errorf("truncated, need %d bytes, %d left", n, len(r.data)-r.off)
+	}
+	b := r.data[r.off : r.off+n]
+	r.off += n
+	return b, nil
+}
+
+func (r *easyReader) uint32() (uint32, error) {
+	b, err := r.next(4)
+	if err != nil {
+		return 0, err
+	}
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16 | uint32(b[3])<<24, nil
+}
+
+// header skip the optional header, negotiating its version. Headerless data gives an empty version
+func (r *easyReader) header() (EasyVersion, error) {
+	if len(r.data)-r.off < EC_MAGIC_NUM_LEN || !bytes.Equal(r.data[r.off:r.off+EC_MAGIC_NUM_LEN], ecMagicNum) {
+		return "", nil
+	}
+	r.off += EC_MAGIC_NUM_LEN
+	version, err := r.next(EC_VERSION_LEN)
+	if err != nil {
+		return "", err
+	}
+	if !easyVersions[EasyVersion(version)] {
+		return "", r.errorf("unsupported version %q", version)
+	}
+	reserved, err := r.next(EC_RESERVED_LEN)
+	if err != nil {
+		return "", err
+	}
+	if !bytes.Equal(reserved, ecReserved) {
+		return "", r.errorf("invalid reserved field")
+	}
+	return EasyVersion(version), nil
+}
+
+func (r *easyReader) errorf(format string, a ...interface{}) error {
+	return fmt.Errorf("easycodec at offset %d: %s: %w", r.off, fmt.Sprintf(format, a...), ErrCodec)
+}
+
+// unmarshalValue deserialize val, nested codecs are deserialized by EasyUnmarshalStrict when strict
+func unmarshalValue(valueType EasyValueType, val []byte, depth int, strict bool) (interface{}, bool) {
+	switch valueType {
+	case EasyValueType_INT32:
+		if len(val) != 4 {
+			return nil, false
+		}
+		return int32(binaryUint32Unmarshal(bytes.NewBuffer(val), make([]byte, 4))), true
+	case EasyValueType_STRING:
+		return string(val), true
+	case EasyValueType_BYTES:
+		// do not alias data
+		return append([]byte{}, val...), true
+	case EasyValueType_INT64:
+		if len(val) != 8 {
+			return nil, false
+		}
+		return int64(binaryUint64Unmarshal(val)), true
+	case EasyValueType_UINT64:
+		if len(val) != 8 {
+			return nil, false
+		}
+		return binaryUint64Unmarshal(val), true
+	case EasyValueType_BOOL:
+		if len(val) != 1 || val[0] > 1 {
+			return nil, false
+		}
+		return val[0] == 1, true
+	case EasyValueType_DECIMAL:
+		d, err := ParseEasyDecimal(string(val))
+		return d, err == nil
+	case EasyValueType_CODEC:
+		if depth >= MAX_DEPTH {
+			return nil, false
+		}
+		if strict {
+			items, err := easyUnmarshalStrict(val, depth+1)
+			return &EasyCodec{items: items}, err == nil
+		}
+		return &EasyCodec{items: easyUnmarshal(val, depth+1)}, true
+	case EasyValueType_LIST:
+		if depth >= MAX_DEPTH || len(val) < 4 {
+			return nil, false
+		}
+		count := binaryUint32Unmarshal(bytes.NewBuffer(val[:4]), make([]byte, 4))
+		if count > MAX_KEY_COUNT {
+			return nil, false
+		}
+		val = val[4:]
+		values := make([]interface{}, 0, minInt(int(count), len(val)/8))
+		for i := uint32(0); i < count; i++ {
+			if len(val) < 8 {
+				return nil, false
+			}
+			elemType := EasyValueType(binaryUint32Unmarshal(bytes.NewBuffer(val[:4]), make([]byte, 4)))
+			elemLen := binaryUint32Unmarshal(bytes.NewBuffer(val[4:8]), make([]byte, 4))
+			val = val[8:]
+			if uint64(elemLen) > uint64(len(val)) {
+				return nil, false
+			}
+			elem, ok := unmarshalValue(elemType, val[:elemLen], depth+1, strict)
+			if !ok {
+				return nil, false
+			}
+			values = append(values, elem)
+			val = val[elemLen:]
+		}
+		// the serialization of the list ends with its last element
+		if strict && len(val) != 0 {
+			return nil, false
+		}
+		return values, true
+	}
+	return nil, false
+}
+
+func binaryUint64Marshal(data uint64) []byte {
+	b := make([]byte, 8)
+	for i := 0; i < 8; i++ {
+		b[i] = byte(data >> (8 * i))
+	}
+	return b
+}
+
+func binaryUint64Unmarshal(b []byte) uint64 {
+	_ = b[7]
+	var data uint64
+	for i := 0; i < 8; i++ {
+		data |= uint64(b[i]) << (8 * i)
+	}
+	return data
+}
+
 func binaryUint32Marshal(buf *bytes.Buffer, data uint32, dataBytes []byte) {
 	_ = dataBytes[3]
 	dataBytes[0] = byte(data)
@@ -0,0 +1,298 @@
+/*
+Copyright (C) BABEC. All rights reserved.
+Copyright (C) THL A29 Limited, a Tencent company. All rights reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package sdk
+
+import (
+	"fmt"
+)
+
+// allocation free building blocks of EasyCodec serialization, used by the code generated by cmd/ecgen.
+// The layout is the one of EasyMarshal, without header
+
+// EasyItemSize return the serialized size of an item with key and a val of valLen bytes
+func EasyItemSize(key string, valLen int) int {
+	return 16 + len(key) + valLen
+}
+
+// AppendEasyCount append the itemCount
+func AppendEasyCount(dst []byte, count int) []byte {
+	return appendUint32(dst, uint32(count))
+}
+
+func appendUint32(dst []byte, data uint32) []byte {
+	return append(dst, byte(data), byte(data>>8), byte(data>>16), byte(data>>24))
+}
+
+func appendUint64(dst []byte, data uint64) []byte {
+	return append(dst, byte(data), byte(data>>8), byte(data>>16), byte(data>>24),
+		byte(data>>32), byte(data>>40), byte(data>>48), byte(data>>56))
+}
+
+func appendEasyItemHead(dst []byte, keyType EasyKeyType, key string, valueType EasyValueType, valLen int) []byte {
+	dst = appendUint32(dst, uint32(keyType))
+	dst = appendUint32(dst, uint32(len(key)))
+	dst = append(dst, key...)
+	dst = appendUint32(dst, uint32(valueType))
+	return appendUint32(dst, uint32(valLen))
+}
+
+// AppendEasyInt32 append a user item of type INT32
+func AppendEasyInt32(dst []byte, key string, value int32) []byte {
+	return appendUint32(appendEasyItemHead(dst, EasyKeyType_USER, key, EasyValueType_INT32, 4), uint32(value))
+}
+
+// AppendEasyInt64 append a user item of type INT64
+func AppendEasyInt64(dst []byte, key string, value int64) []byte {
+	return appendUint64(appendEasyItemHead(dst, EasyKeyType_USER, key, EasyValueType_INT64, 8), uint64(value))
+}
+
+// AppendEasyUint64 append a user item of type UINT64
+func AppendEasyUint64(dst []byte, key string, value uint64) []byte {
+	return appendUint64(appendEasyItemHead(dst, EasyKeyType_USER, key, EasyValueType_UINT64, 8), value)
+}
+
+// AppendEasyBool append a user item of type BOOL
+func AppendEasyBool(dst []byte, key string, value bool) []byte {
+	var b byte
+	if value {
+		b = 1
+	}
+	return append(appendEasyItemHead(dst, EasyKeyType_USER, key, EasyValueType_BOOL, 1), b)
+}
+
+// AppendEasyString append a user item of type STRING
+func AppendEasyString(dst []byte, key string, value string) []byte {
+	return append(appendEasyItemHead(dst, EasyKeyType_USER, key, EasyValueType_STRING, len(value)), value...)
+}
+
+// AppendEasyDecimal append a user item of type DECIMAL, value is not checked and must be valid,
+// see ParseEasyDecimal
+func AppendEasyDecimal(dst []byte, key string, value EasyDecimal) []byte {
+	return append(appendEasyItemHead(dst, EasyKeyType_USER, key, EasyValueType_DECIMAL, len(value)), value...)
+}
+
+// AppendEasyBytes append a user item of type BYTES
+func AppendEasyBytes(dst []byte, key string, value []byte) []byte {
+	return append(appendEasyItemHead(dst, EasyKeyType_USER, key, EasyValueType_BYTES, len(value)), value...)
+}
+
+// AppendEasyCodec append a user item of type CODEC, value is a serialization without header
+func AppendEasyCodec(dst []byte, key string, value []byte) []byte {
+	return append(appendEasyItemHead(dst, EasyKeyType_USER, key, EasyValueType_CODEC, len(value)), value...)
+}
+
+// EasyScanner iterate over serialized items without allocating, with the validation of
+// EasyUnmarshalStrict. Key and Value alias the scanned data:
+//
+//	var s sdk.EasyScanner
+//	s.Reset(data)
+//	for s.Next() {
+//		switch string(s.Key()) {
+//		case "amount":
+//			amount, err = s.Int64()
+//		}
+//	}
+//	if err := s.Err(); err != nil {
+//		...
+//	}
+type EasyScanner struct {
+	r         easyReader
+	remaining uint32
+	keyType   EasyKeyType
+	key       []byte
+	valueType EasyValueType
+	value     []byte
+	err       error
+}
+
+// Reset start scanning data, a header is accepted
+func (s *EasyScanner) Reset(data []byte) {
+	*s = EasyScanner{r: easyReader{data: data}}
+	if _, s.err = s.r.header(); s.err != nil {
+		return
+	}
+	if s.remaining, s.err = s.r.uint32(); s.err == nil && s.remaining > MAX_KEY_COUNT {
+		s.err = s.r.errorf("item count %d over limit %d", s.remaining, MAX_KEY_COUNT)
+	}
+}
+
+// Next advance to the next item, false at the end or on error
+func (s *EasyScanner) Next() bool {
+	if s.err != nil {
+		return false
+	}
+	if s.remaining == 0 {
+		if s.r.off != len(s.r.data) {
+			s.err = s.r.errorf("%d trailing bytes", len(s.r.data)-s.r.off)
+		}
+		return false
+	}
+	s.remaining--
+	keyType, err := s.r.uint32()
+	if err != nil {
+		s.err = err
+		return false
+	}
+	s.keyType = EasyKeyType(keyType)
+	if s.keyType != EasyKeyType_SYSTEM && s.keyType != EasyKeyType_USER {
+		s.err = s.r.errorf("unknown key type %d", keyType)
+		return false
+	}
+	keyLength, err := s.r.uint32()
+	if err == nil && keyLength > MAX_KEY_LEN {
+		err = s.r.errorf("key length %d over limit %d", keyLength, MAX_KEY_LEN)
+	}
+	if err == nil {
+		s.key, err = s.r.next(int(keyLength))
+	}
+	if err != nil {
+		s.err = err
+		return false
+	}
+	valueType, err := s.r.uint32()
+	if err != nil {
+		s.err = err
+		return false
+	}
+	s.valueType = EasyValueType(valueType)
+	valueLength, err := s.r.uint32()
+	if err == nil && valueLength > MAX_VALUE_LEN {
+		err = s.r.errorf("value length %d over limit %d", valueLength, MAX_VALUE_LEN)
+	}
+	if err == nil {
+		s.value, err = s.r.next(int(valueLength))
+	}
+	if err != nil {
+		s.err = err
+		return false
+	}
+	return true
+}
+
+// Err return the first error met by Next
+func (s *EasyScanner) Err() error {
+	return s.err
+}
+
+// KeyType return the key type of the current item
+func (s *EasyScanner) KeyType() EasyKeyType {
+	return s.keyType
+}
+
+// Key return the key of the current item
+func (s *EasyScanner) Key() []byte {
+	return s.key
+}
+
+// ValueType return the value type of the current item
+func (s *EasyScanner) ValueType() EasyValueType {
+	return s.valueType
+}
+
+// Value return the val of the current item
+func (s *EasyScanner) Value() []byte {
+	return s.value
+}
+
+func (s *EasyScanner) expect(valueType EasyValueType, valLen int) error {
+	if s.valueType != valueType || (valLen >= 0 && len(s.value) != valLen) {
+		return fmt.Errorf("key %q: value type %d with %d bytes, want %d: %w",
+			s.key, s.valueType, len(s.value), valueType, ErrCodec)
+	}
+	return nil
+}
+
+// Int32 decode the current INT32 value
+func (s *EasyScanner) Int32() (int32, error) {
+	if err := s.expect(EasyValueType_INT32, 4); err != nil {
+		return 0, err
+	}
+	v := s.value
+	return int32(uint32(v[0]) | uint32(v[1])<<8 | uint32(v[2])<<16 | uint32(v[3])<<24), nil
+}
+
+// Int64 decode the current INT64 value
+func (s *EasyScanner) Int64() (int64, error) {
+	if err := s.expect(EasyValueType_INT64, 8); err != nil {
+		return 0, err
+	}
+	return int64(binaryUint64Unmarshal(s.value)), nil
+}
+
+// Uint64 decode the current UINT64 value
+func (s *EasyScanner) Uint64() (uint64, error) {
+	if err := s.expect(EasyValueType_UINT64, 8); err != nil {
+		return 0, err
+	}
+	return binaryUint64Unmarshal(s.value), nil
+}
+
+// Bool decode the current BOOL value
+func (s *EasyScanner) Bool() (bool, error) {
+	if err := s.expect(EasyValueType_BOOL, 1); err != nil {
+		return false, err
+	}
+	if s.value[0] > 1 {
+		return false, fmt.Errorf("key %q: invalid bool %d: %w", s.key, s.value[0], ErrCodec)
+	}
+	return s.value[0] == 1, nil
+}
+
+// String decode the current STRING value
+func (s *EasyScanner) String() (string, error) {
+	if err := s.expect(EasyValueType_STRING, -1); err != nil {
+		return "", err
+	}
+	return string(s.value), nil
+}
+
+// Decimal decode the current DECIMAL value
+func (s *EasyScanner) Decimal() (EasyDecimal, error) {
+	if err := s.expect(EasyValueType_DECIMAL, -1); err != nil {
+		return "", err
+	}
+	d, err := ParseEasyDecimal(string(s.value))
+	if err != nil {
+		return "", fmt.Errorf("key %q: %v: %w", s.key, err, ErrCodec)
+	}
+	return d, nil
+}
+
+// Bytes return a copy of the current BYTES value
+func (s *EasyScanner) Bytes() ([]byte, error) {
+	if err := s.expect(EasyValueType_BYTES, -1); err != nil {
+		return nil, err
+	}
+	return append([]byte{}, s.value...), nil
+}
+
+// Codec return the current CODEC value, aliasing the scanned data
+func (s *EasyScanner) Codec() ([]byte, error) {
+	if err := s.expect(EasyValueType_CODEC, -1); err != nil {
+		return nil, err
+	}
+	return s.value, nil
+}
+
+// Int decode the current INT64 value as an int, checking overflow
+func (s *EasyScanner) Int() (int, error) {
+	v, err := s.Int64()
+	if err == nil && int64(int(v)) != v {
+		err = fmt.Errorf("key %q: %d overflows int: %w", s.key, v, ErrCodec)
+	}
+	return int(v), err
+}
+
+// Uint decode the current UINT64 value as an uint, checking overflow
+func (s *EasyScanner) Uint() (uint, error) {
+	v, err := s.Uint64()
+	if err == nil && uint64(uint(v)) != v {
+		err = fmt.Errorf("key %q: %d overflows uint: %w", s.key, v, ErrCodec)
+	}
+	return uint(v), err
+}
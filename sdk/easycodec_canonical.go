@@ -0,0 +1,124 @@
+/*
+Copyright (C) BABEC. All rights reserved.
+Copyright (C) THL A29 Limited, a Tencent company. All rights reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package sdk
+
+import (
+	"crypto"
+	// register crypto.SHA256 for Hash
+	_ "crypto/sha256"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Canonical serialize with the header, the items sorted by key type then key bytes and nested
+// codecs sorted the same way, so that equal content always gives equal bytes whatever the order
+// of Add. Decimals are normalized, "1.50" and "-0" are written "1.5" and "0". A duplicate key,
+// an unknown type or a value not matching its type is an error, where Marshal would keep or skip
+// it. List elements keep their order
+func (e *EasyCodec) Canonical() ([]byte, error) {
+	items, err := canonicalItems(e.items, 0)
+	if err != nil {
+		return nil, err
+	}
+	return EasyMarshalWithHeader(items), nil
+}
+
+// Hash return the digest of Canonical with alg, as crypto.SHA256. Other algorithms must be
+// linked in by importing their package
+func (e *EasyCodec) Hash(alg crypto.Hash) ([]byte, error) {
+	if !alg.Available() {
+		return nil, fmt.Errorf("hash %d not available", alg)
+	}
+	data, err := e.Canonical()
+	if err != nil {
+		return nil, err
+	}
+	h := alg.New()
+	h.Write(data)
+	return h.Sum(nil), nil
+}
+
+func canonicalItems(items []*EasyCodecItem, depth int) ([]*EasyCodecItem, error) {
+	if depth > MAX_DEPTH {
+		return nil, fmt.Errorf("nesting over depth %d: %w", MAX_DEPTH, ErrCodec)
+	}
+	sorted := make([]*EasyCodecItem, 0, len(items))
+	for _, item := range items {
+		if item.KeyType != EasyKeyType_SYSTEM && item.KeyType != EasyKeyType_USER {
+			return nil, fmt.Errorf("key %q: unknown key type %d: %w", item.Key, item.KeyType, ErrCodec)
+		}
+		value, err := canonicalValue(item.ValueType, item.Value, depth)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", item.Key, err)
+		}
+		sorted = append(sorted, newEasyCodecItem(item.KeyType, item.Key, item.ValueType, value))
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].KeyType != sorted[j].KeyType {
+			return sorted[i].KeyType < sorted[j].KeyType
+		}
+		return sorted[i].Key < sorted[j].Key
+	})
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i].KeyType == sorted[i-1].KeyType && sorted[i].Key == sorted[i-1].Key {
+			return nil, fmt.Errorf("key %q: %w", sorted[i].Key, ErrDuplicateKey)
+		}
+	}
+	return sorted, nil
+}
+
+// canonicalValue check value against valueType, nested codecs are replaced by their canonical form
+func canonicalValue(valueType EasyValueType, value interface{}, depth int) (interface{}, error) {
+	if actual, ok := EasyValueOf(value); !ok || actual != valueType {
+		return nil, fmt.Errorf("%T value for value type %d: %w", value, valueType, ErrCodec)
+	}
+	switch valueType {
+	case EasyValueType_DECIMAL:
+		d, err := ParseEasyDecimal(string(value.(EasyDecimal)))
+		if err != nil {
+			return nil, err
+		}
+		return normalizeDecimal(d), nil
+	case EasyValueType_CODEC:
+		items, err := canonicalItems(value.(*EasyCodec).items, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		return NewEasyCodecWithItems(items), nil
+	case EasyValueType_LIST:
+		if depth+1 > MAX_DEPTH {
+			return nil, fmt.Errorf("nesting over depth %d: %w", MAX_DEPTH, ErrCodec)
+		}
+		values := value.([]interface{})
+		elems := make([]interface{}, 0, len(values))
+		for _, v := range values {
+			elemType, _ := EasyValueOf(v)
+			elem, err := canonicalValue(elemType, v, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			elems = append(elems, elem)
+		}
+		return elems, nil
+	}
+	return value, nil
+}
+
+// normalizeDecimal strip the trailing zeros of the fraction and the sign of zero, d is valid
+func normalizeDecimal(d EasyDecimal) EasyDecimal {
+	s := string(d)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(s, "0")
+		s = strings.TrimSuffix(s, ".")
+	}
+	if s == "-0" {
+		s = "0"
+	}
+	return EasyDecimal(s)
+}
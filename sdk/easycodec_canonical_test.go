@@ -0,0 +1,88 @@
+/*
+Copyright (C) BABEC. All rights reserved.
+Copyright (C) THL A29 Limited, a Tencent company. All rights reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package sdk
+
+import (
+	"bytes"
+	"crypto"
+	"errors"
+	"testing"
+)
+
+func TestCanonicalHashIgnoresOrderAndDecimalSpelling(t *testing.T) {
+	nestedA := NewEasyCodec()
+	nestedA.AddDecimal("zero", "-0.00")
+	nestedA.AddString("s", "x")
+	a := NewEasyCodec()
+	a.AddValue(EasyKeyType_SYSTEM, "sys", EasyValueType_STRING, "y")
+	a.AddString("b", "v")
+	a.AddDecimal("price", "1.50")
+	a.AddCodec("nested", nestedA)
+	_ = a.AddList("list", []interface{}{EasyDecimal("10.0"), int32(1)})
+
+	nestedB := NewEasyCodec()
+	nestedB.AddString("s", "x")
+	nestedB.AddDecimal("zero", "0")
+	b := NewEasyCodec()
+	_ = b.AddList("list", []interface{}{EasyDecimal("10"), int32(1)})
+	b.AddCodec("nested", nestedB)
+	b.AddDecimal("price", "1.5")
+	b.AddString("b", "v")
+	b.AddValue(EasyKeyType_SYSTEM, "sys", EasyValueType_STRING, "y")
+
+	hashA, err := a.Hash(crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashB, err := b.Hash(crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(hashA, hashB) {
+		canonicalA, _ := a.Canonical()
+		canonicalB, _ := b.Canonical()
+		t.Fatalf("hashes differ for equal content:\n%q\n%q", canonicalA, canonicalB)
+	}
+
+	b.SetDecimal("price", "1.51")
+	if hashB, _ = b.Hash(crypto.SHA256); bytes.Equal(hashA, hashB) {
+		t.Fatal("hash ignores a different decimal")
+	}
+}
+
+func TestCanonicalKeepsListOrder(t *testing.T) {
+	a, b := NewEasyCodec(), NewEasyCodec()
+	_ = a.AddList("l", []interface{}{int32(1), int32(2)})
+	_ = b.AddList("l", []interface{}{int32(2), int32(1)})
+	canonicalA, _ := a.Canonical()
+	canonicalB, _ := b.Canonical()
+	if bytes.Equal(canonicalA, canonicalB) {
+		t.Fatal("list order is lost")
+	}
+}
+
+func TestCanonicalErrors(t *testing.T) {
+	dup := NewEasyCodec()
+	dup.AddString("k", "a")
+	dup.AddString("k", "b")
+	if _, err := dup.Canonical(); !errors.Is(err, ErrDuplicateKey) {
+		t.Fatalf("duplicate key: got %v", err)
+	}
+
+	mismatch := NewEasyCodec()
+	mismatch.AddValue(EasyKeyType_USER, "k", EasyValueType_INT32, "not an int32")
+	if _, err := mismatch.Canonical(); !errors.Is(err, ErrCodec) {
+		t.Fatalf("type mismatch: got %v", err)
+	}
+
+	badDecimal := NewEasyCodec()
+	badDecimal.AddValue(EasyKeyType_USER, "k", EasyValueType_DECIMAL, EasyDecimal("01.5"))
+	if _, err := badDecimal.Canonical(); !errors.Is(err, ErrCodec) {
+		t.Fatalf("bad decimal: got %v", err)
+	}
+}
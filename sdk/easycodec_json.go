@@ -0,0 +1,373 @@
+/*
+Copyright (C) BABEC. All rights reserved.
+Copyright (C) THL A29 Limited, a Tencent company. All rights reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package sdk
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// typed json envelope of EasyCodec, unlike ToJson it keeps the key type, value type and order of
+// the items, so that EasyCodec -> json -> EasyCodec is lossless:
+//
+//	[
+//		{"keyType":"USER","key":"to","type":"STRING","value":"alice"},
+//		{"keyType":"USER","key":"amount","type":"INT64","value":"-5"},
+//		{"keyType":"USER","key":"tags","type":"LIST","value":[{"type":"INT32","value":1}]}
+//	]
+//
+// value by type: INT32 number, STRING string, BYTES base64 string, INT64 and UINT64 decimal string
+// (a number is also accepted), BOOL bool, DECIMAL string, CODEC nested envelope, LIST array of
+// {"type","value"}. A json string can not hold invalid utf8, so a key or STRING value holding it
+// is ErrCodec, BYTES carry any bytes
+
+var easyKeyTypeNames = map[EasyKeyType]string{
+	EasyKeyType_SYSTEM: "SYSTEM",
+	EasyKeyType_USER:   "USER",
+}
+
+var easyValueTypeNames = map[EasyValueType]string{
+	EasyValueType_INT32:   "INT32",
+	EasyValueType_STRING:  "STRING",
+	EasyValueType_BYTES:   "BYTES",
+	EasyValueType_INT64:   "INT64",
+	EasyValueType_UINT64:  "UINT64",
+	EasyValueType_BOOL:    "BOOL",
+	EasyValueType_DECIMAL: "DECIMAL",
+	EasyValueType_CODEC:   "CODEC",
+	EasyValueType_LIST:    "LIST",
+}
+
+// MarshalJSON serialize as the typed json envelope, the output is deterministic
+func (e *EasyCodec) MarshalJSON() ([]byte, error) {
+	var build strings.Builder
+	if err := writeJsonEnvelope(&build, e.items, 0); err != nil {
+		return nil, err
+	}
+	return []byte(build.String()), nil
+}
+
+func writeJsonEnvelope(build *strings.Builder, items []*EasyCodecItem, depth int) error {
+	if depth > MAX_DEPTH {
+		return fmt.Errorf("nesting over depth %d: %w", MAX_DEPTH, ErrCodec)
+	}
+	build.WriteString("[")
+	for i, item := range items {
+		keyType, ok := easyKeyTypeNames[item.KeyType]
+		if !ok {
+			return fmt.Errorf("key %q: unknown key type %d: %w", item.Key, item.KeyType, ErrCodec)
+		}
+		if i > 0 {
+			build.WriteString(",")
+		}
+		build.WriteString(`{"keyType":"`)
+		build.WriteString(keyType)
+		build.WriteString(`","key":`)
+		if !utf8.ValidString(item.Key) {
+			return fmt.Errorf("key %q: invalid utf8: %w", item.Key, ErrCodec)
+		}
+		writeJsonString(build, item.Key)
+		build.WriteString(",")
+		if err := writeJsonTypedValue(build, item.ValueType, item.Value, depth); err != nil {
+			return fmt.Errorf("key %q: %w", item.Key, err)
+		}
+		build.WriteString("}")
+	}
+	build.WriteString("]")
+	return nil
+}
+
+// writeJsonTypedValue write "type":..,"value":..
+func writeJsonTypedValue(build *strings.Builder, valueType EasyValueType, value interface{}, depth int) error {
+	name, ok := easyValueTypeNames[valueType]
+	if !ok {
+		return fmt.Errorf("unknown value type %d: %w", valueType, ErrCodec)
+	}
+	if elemType, ok := EasyValueOf(value); !ok || elemType != valueType {
+		return fmt.Errorf("%T value for type %s: %w", value, name, ErrCodec)
+	}
+	build.WriteString(`"type":"`)
+	build.WriteString(name)
+	build.WriteString(`","value":`)
+	switch valueType {
+	case EasyValueType_STRING:
+		if !utf8.ValidString(value.(string)) {
+			return fmt.Errorf("string %q: invalid utf8: %w", value, ErrCodec)
+		}
+		writeJsonString(build, value.(string))
+	case EasyValueType_INT64:
+		build.WriteString(strconv.Quote(strconv.FormatInt(value.(int64), 10)))
+	case EasyValueType_UINT64:
+		build.WriteString(strconv.Quote(strconv.FormatUint(value.(uint64), 10)))
+	case EasyValueType_DECIMAL:
+		// UnmarshalJSON rejects it, the round trip would fail
+		if _, err := ParseEasyDecimal(string(value.(EasyDecimal))); err != nil {
+			return err
+		}
+		writeJsonString(build, string(value.(EasyDecimal)))
+	case EasyValueType_CODEC:
+		return writeJsonEnvelope(build, value.(*EasyCodec).items, depth+1)
+	case EasyValueType_LIST:
+		if depth+1 > MAX_DEPTH {
+			return fmt.Errorf("nesting over depth %d: %w", MAX_DEPTH, ErrCodec)
+		}
+		build.WriteString("[")
+		for i, elem := range value.([]interface{}) {
+			if i > 0 {
+				build.WriteString(",")
+			}
+			elemType, _ := EasyValueOf(elem)
+			build.WriteString("{")
+			if err := writeJsonTypedValue(build, elemType, elem, depth+1); err != nil {
+				return err
+			}
+			build.WriteString("}")
+		}
+		build.WriteString("]")
+	default:
+		writeJsonValue(build, valueType, value)
+	}
+	return nil
+}
+
+// writeJsonString write s as a json string, escaping quotes, backslashes, control chars and
+// invalid utf8
+func writeJsonString(build *strings.Builder, s string) {
+	const hex = "0123456789abcdef"
+	build.WriteString(`"`)
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			switch {
+			case c == '"' || c == '\\':
+				build.WriteByte('\\')
+				build.WriteByte(c)
+			case c == '\n':
+				build.WriteString(`\n`)
+			case c == '\r':
+				build.WriteString(`\r`)
+			case c == '\t':
+				build.WriteString(`\t`)
+			case c < 0x20:
+				build.WriteString(`\u00`)
+				build.WriteByte(hex[c>>4])
+				build.WriteByte(hex[c&0xf])
+			default:
+				build.WriteByte(c)
+			}
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			build.WriteString(`\ufffd`)
+		case r == '\u2028' || r == '\u2029':
+			// valid json, but not valid javascript
+			build.WriteString(`\u202`)
+			build.WriteByte(hex[r&0xf])
+		default:
+			build.WriteString(s[i : i+size])
+		}
+		i += size
+	}
+	build.WriteString(`"`)
+}
+
+type jsonEasyItem struct {
+	KeyType string          `json:"keyType"`
+	Key     string          `json:"key"`
+	Type    string          `json:"type"`
+	Value   json.RawMessage `json:"value"`
+}
+
+type jsonEasyValue struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+// UnmarshalJSON replace the items with the ones of a typed json envelope, any unknown field, type
+// or value not matching its type is an error wrapping ErrCodec
+func (e *EasyCodec) UnmarshalJSON(data []byte) error {
+	items, err := unmarshalJsonEnvelope(data, 0)
+	if err != nil {
+		return err
+	}
+	e.items = items
+	if e.index != nil {
+		e.EnableIndex()
+	}
+	return nil
+}
+
+func decodeJsonStrict(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("%v: %w", err, ErrCodec)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return fmt.Errorf("trailing data after json value: %w", ErrCodec)
+	}
+	return nil
+}
+
+func unmarshalJsonEnvelope(data []byte, depth int) ([]*EasyCodecItem, error) {
+	if depth > MAX_DEPTH {
+		return nil, fmt.Errorf("nesting over depth %d: %w", MAX_DEPTH, ErrCodec)
+	}
+	var raw []jsonEasyItem
+	if err := decodeJsonStrict(data, &raw); err != nil {
+		return nil, err
+	}
+	if len(raw) > MAX_KEY_COUNT {
+		return nil, fmt.Errorf("item count %d over limit %d: %w", len(raw), MAX_KEY_COUNT, ErrCodec)
+	}
+	items := make([]*EasyCodecItem, 0, len(raw))
+	for _, r := range raw {
+		keyType, ok := easyKeyTypeFromName(r.KeyType)
+		if !ok {
+			return nil, fmt.Errorf("key %q: unknown key type %q: %w", r.Key, r.KeyType, ErrCodec)
+		}
+		if len(r.Key) > MAX_KEY_LEN {
+			return nil, fmt.Errorf("key length %d over limit %d: %w", len(r.Key), MAX_KEY_LEN, ErrCodec)
+		}
+		valueType, value, err := unmarshalJsonTypedValue(r.Type, r.Value, depth)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", r.Key, err)
+		}
+		items = append(items, newEasyCodecItem(keyType, r.Key, valueType, value))
+	}
+	return items, nil
+}
+
+func easyKeyTypeFromName(name string) (EasyKeyType, bool) {
+	for keyType, n := range easyKeyTypeNames {
+		if n == name {
+			return keyType, true
+		}
+	}
+	return 0, false
+}
+
+func easyValueTypeFromName(name string) (EasyValueType, bool) {
+	for valueType, n := range easyValueTypeNames {
+		if n == name {
+			return valueType, true
+		}
+	}
+	return 0, false
+}
+
+func unmarshalJsonTypedValue(name string, data json.RawMessage, depth int) (EasyValueType, interface{}, error) {
+	valueType, ok := easyValueTypeFromName(name)
+	if !ok {
+		return 0, nil, fmt.Errorf("unknown value type %q: %w", name, ErrCodec)
+	}
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return 0, nil, fmt.Errorf("missing value: %w", ErrCodec)
+	}
+	var err error
+	switch valueType {
+	case EasyValueType_INT32:
+		var v int32
+		err = decodeJsonStrict(data, &v)
+		return valueType, v, err
+	case EasyValueType_STRING:
+		var v string
+		err = decodeJsonStrict(data, &v)
+		return valueType, v, err
+	case EasyValueType_BYTES:
+		var s string
+		if err = decodeJsonStrict(data, &s); err != nil {
+			return valueType, nil, err
+		}
+		v, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return valueType, nil, fmt.Errorf("%v: %w", err, ErrCodec)
+		}
+		return valueType, v, nil
+	case EasyValueType_INT64:
+		var v int64
+		err = decodeJsonInteger(data, func(s string) (err error) {
+			v, err = strconv.ParseInt(s, 10, 64)
+			return err
+		})
+		return valueType, v, err
+	case EasyValueType_UINT64:
+		var v uint64
+		err = decodeJsonInteger(data, func(s string) (err error) {
+			v, err = strconv.ParseUint(s, 10, 64)
+			return err
+		})
+		return valueType, v, err
+	case EasyValueType_BOOL:
+		var v bool
+		err = decodeJsonStrict(data, &v)
+		return valueType, v, err
+	case EasyValueType_DECIMAL:
+		var s string
+		if err = decodeJsonStrict(data, &s); err != nil {
+			return valueType, nil, err
+		}
+		v, err := ParseEasyDecimal(s)
+		if err != nil {
+			return valueType, nil, fmt.Errorf("%v: %w", err, ErrCodec)
+		}
+		return valueType, v, nil
+	case EasyValueType_CODEC:
+		items, err := unmarshalJsonEnvelope(data, depth+1)
+		if err != nil {
+			return valueType, nil, err
+		}
+		return valueType, NewEasyCodecWithItems(items), nil
+	}
+	// EasyValueType_LIST
+	if depth+1 > MAX_DEPTH {
+		return valueType, nil, fmt.Errorf("nesting over depth %d: %w", MAX_DEPTH, ErrCodec)
+	}
+	var raw []jsonEasyValue
+	if err = decodeJsonStrict(data, &raw); err != nil {
+		return valueType, nil, err
+	}
+	values := make([]interface{}, 0, len(raw))
+	for _, r := range raw {
+		_, v, err := unmarshalJsonTypedValue(r.Type, r.Value, depth+1)
+		if err != nil {
+			return valueType, nil, err
+		}
+		values = append(values, v)
+	}
+	return valueType, values, nil
+}
+
+// decodeJsonInteger parse a decimal string or a json number without fraction nor exponent
+func decodeJsonInteger(data json.RawMessage, parse func(s string) error) error {
+	var s string
+	if len(data) > 0 && data[0] == '"' {
+		if err := decodeJsonStrict(data, &s); err != nil {
+			return err
+		}
+	} else {
+		var n json.Number
+		if err := decodeJsonStrict(data, &n); err != nil {
+			return err
+		}
+		s = n.String()
+	}
+	if err := parse(s); err != nil {
+		return fmt.Errorf("invalid integer %q: %w", s, ErrCodec)
+	}
+	return nil
+}
@@ -0,0 +1,395 @@
+/*
+Copyright (C) BABEC. All rights reserved.
+Copyright (C) THL A29 Limited, a Tencent company. All rights reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package sdk
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// struct fields map onto EasyCodec items by the ec tag, as:
+//
+//	type Transfer struct {
+//		To     string      `ec:"to,required"`
+//		Amount EasyDecimal `ec:"amount,required"`
+//		Memo   string      `ec:"memo,omitempty"`
+//		Skip   int         `ec:"-"`
+//	}
+//
+// An untagged exported field uses its name as key. Field types map onto value types:
+//
+//	int32:                      INT32
+//	int, int8, int16, int64:    INT64
+//	uint, uint8 ... uint64:     UINT64
+//	string:                     STRING
+//	[]byte, [N]byte:            BYTES, also for named byte types
+//	bool:                       BOOL
+//	EasyDecimal:                DECIMAL
+//	struct, *struct:            CODEC
+//	other slices and arrays:    LIST
+//
+// UnmarshalStruct also accepts STRING and BYTES values for numbers and bools, since the args of
+// a transaction are all bytes. An array is only set from as many bytes or elements as it holds.
+// Values nest up to MAX_DEPTH, as in EasyUnmarshal, so a cyclic pointer is ErrCodec.
+
+var easyDecimalType = reflect.TypeOf(EasyDecimal(""))
+
+// isBytesType whether t is a slice or array of bytes, named or not
+func isBytesType(t reflect.Type) bool {
+	return (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && t.Elem().Kind() == reflect.Uint8
+}
+
+type structField struct {
+	index     int
+	key       string
+	required  bool
+	omitEmpty bool
+}
+
+func structFields(t reflect.Type) []structField {
+	fields := make([]structField, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		tag := f.Tag.Get("ec")
+		if tag == "-" {
+			continue
+		}
+		parts := strings.Split(tag, ",")
+		field := structField{index: i, key: parts[0]}
+		if field.key == "" {
+			field.key = f.Name
+		}
+		for _, opt := range parts[1:] {
+			switch opt {
+			case "required":
+				field.required = true
+			case "omitempty":
+				field.omitEmpty = true
+			}
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// MarshalStruct add the fields of the struct v, or pointer to struct, as user items.
+// Nil pointer fields are skipped, a nil required one is ErrCodec
+func (e *EasyCodec) MarshalStruct(v interface{}) error {
+	if v == nil {
+		return fmt.Errorf("marshal nil: %w", ErrCodec)
+	}
+	return e.marshalStruct(reflect.ValueOf(v), 0)
+}
+
+func (e *EasyCodec) marshalStruct(rv reflect.Value, depth int) error {
+	t := rv.Type()
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return fmt.Errorf("marshal nil %s: %w", t, ErrCodec)
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("marshal %s, not a struct: %w", t, ErrCodec)
+	}
+	for _, f := range structFields(rv.Type()) {
+		fv := rv.Field(f.index)
+		if f.omitEmpty && fv.IsZero() {
+			continue
+		}
+		if fv.Kind() == reflect.Ptr && fv.IsNil() {
+			if f.required {
+				return fmt.Errorf("nil required field %s: %w", f.key, ErrCodec)
+			}
+			continue
+		}
+		valueType, value, err := structValue(fv, depth)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", f.key, err)
+		}
+		e.AddValue(EasyKeyType_USER, f.key, valueType, value)
+	}
+	return nil
+}
+
+// structValue the item value of v, nested in depth codecs or lists
+func structValue(v reflect.Value, depth int) (EasyValueType, interface{}, error) {
+	if v.Type() == easyDecimalType {
+		d, err := ParseEasyDecimal(v.String())
+		return EasyValueType_DECIMAL, d, err
+	}
+	if isBytesType(v.Type()) {
+		if v.Kind() == reflect.Slice {
+			return EasyValueType_BYTES, v.Bytes(), nil
+		}
+		// arrays are copied by element, Value.Bytes only accepts them since go1.19
+		b := make([]byte, v.Len())
+		for i := range b {
+			b[i] = byte(v.Index(i).Uint())
+		}
+		return EasyValueType_BYTES, b, nil
+	}
+	switch v.Kind() {
+	case reflect.Struct, reflect.Ptr, reflect.Slice, reflect.Array:
+		if depth >= MAX_DEPTH {
+			return 0, nil, fmt.Errorf("nesting over depth %d: %w", MAX_DEPTH, ErrCodec)
+		}
+	}
+	switch v.Kind() {
+	case reflect.Int32:
+		return EasyValueType_INT32, int32(v.Int()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int64:
+		return EasyValueType_INT64, v.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return EasyValueType_UINT64, v.Uint(), nil
+	case reflect.String:
+		return EasyValueType_STRING, v.String(), nil
+	case reflect.Bool:
+		return EasyValueType_BOOL, v.Bool(), nil
+	case reflect.Struct, reflect.Ptr:
+		nested := NewEasyCodec()
+		if err := nested.marshalStruct(v, depth+1); err != nil {
+			return 0, nil, err
+		}
+		return EasyValueType_CODEC, nested, nil
+	case reflect.Slice, reflect.Array:
+		values := make([]interface{}, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			_, value, err := structValue(v.Index(i), depth+1)
+			if err != nil {
+				return 0, nil, err
+			}
+			values = append(values, value)
+		}
+		return EasyValueType_LIST, values, nil
+	}
+	return 0, nil, fmt.Errorf("unsupported type %s: %w", v.Type(), ErrCodec)
+}
+
+// UnmarshalStruct fill the struct pointed by v with the user items of ec.
+// A missing required field or a mismatching value is ErrCodec
+func UnmarshalStruct(ec *EasyCodec, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("unmarshal into %T, not a pointer to struct: %w", v, ErrCodec)
+	}
+	rv = rv.Elem()
+	for _, f := range structFields(rv.Type()) {
+		item, err := ec.GetItem(f.key, EasyKeyType_USER)
+		if err != nil {
+			if f.required {
+				return fmt.Errorf("missing required field %s: %w", f.key, ErrCodec)
+			}
+			continue
+		}
+		if err = setStructValue(rv.Field(f.index), item.ValueType, item.Value); err != nil {
+			return fmt.Errorf("field %s: %w", f.key, err)
+		}
+	}
+	return nil
+}
+
+func setStructValue(v reflect.Value, valueType EasyValueType, value interface{}) error {
+	mismatch := func() error {
+		return fmt.Errorf("can not set value type %d to %s: %w", valueType, v.Type(), ErrCodec)
+	}
+	if v.Type() == easyDecimalType {
+		s, ok := textValue(valueType, value)
+		if !ok {
+			return mismatch()
+		}
+		d, err := ParseEasyDecimal(s)
+		if err != nil {
+			return fmt.Errorf("%v: %w", err, ErrCodec)
+		}
+		v.SetString(string(d))
+		return nil
+	}
+	if isBytesType(v.Type()) {
+		var b []byte
+		switch valueType {
+		case EasyValueType_BYTES:
+			b = value.([]byte)
+		case EasyValueType_STRING:
+			b = []byte(value.(string))
+		default:
+			return mismatch()
+		}
+		if v.Kind() == reflect.Slice {
+			v.SetBytes(b)
+			return nil
+		}
+		if len(b) != v.Len() {
+			return fmt.Errorf("%d bytes for %s: %w", len(b), v.Type(), ErrCodec)
+		}
+		for i, c := range b {
+			v.Index(i).SetUint(uint64(c))
+		}
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var n int64
+		switch valueType {
+		case EasyValueType_INT32:
+			n = int64(value.(int32))
+		case EasyValueType_INT64:
+			n = value.(int64)
+		case EasyValueType_UINT64:
+			if value.(uint64) > math.MaxInt64 {
+				return mismatch()
+			}
+			n = int64(value.(uint64))
+		default:
+			s, ok := textValue(valueType, value)
+			if !ok {
+				return mismatch()
+			}
+			var err error
+			if n, err = strconv.ParseInt(s, 10, 64); err != nil {
+				return fmt.Errorf("%v: %w", err, ErrCodec)
+			}
+		}
+		if v.OverflowInt(n) {
+			return fmt.Errorf("%d overflows %s: %w", n, v.Type(), ErrCodec)
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var n uint64
+		switch valueType {
+		case EasyValueType_INT32:
+			if value.(int32) < 0 {
+				return fmt.Errorf("%d overflows %s: %w", value, v.Type(), ErrCodec)
+			}
+			n = uint64(value.(int32))
+		case EasyValueType_INT64:
+			if value.(int64) < 0 {
+				return fmt.Errorf("%d overflows %s: %w", value, v.Type(), ErrCodec)
+			}
+			n = uint64(value.(int64))
+		case EasyValueType_UINT64:
+			n = value.(uint64)
+		default:
+			s, ok := textValue(valueType, value)
+			if !ok {
+				return mismatch()
+			}
+			var err error
+			if n, err = strconv.ParseUint(s, 10, 64); err != nil {
+				return fmt.Errorf("%v: %w", err, ErrCodec)
+			}
+		}
+		if v.OverflowUint(n) {
+			return fmt.Errorf("%d overflows %s: %w", n, v.Type(), ErrCodec)
+		}
+		v.SetUint(n)
+	case reflect.String:
+		s, ok := textValue(valueType, value)
+		if !ok {
+			return mismatch()
+		}
+		v.SetString(s)
+	case reflect.Bool:
+		if valueType == EasyValueType_BOOL {
+			v.SetBool(value.(bool))
+			return nil
+		}
+		s, ok := textValue(valueType, value)
+		if !ok {
+			return mismatch()
+		}
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("%v: %w", err, ErrCodec)
+		}
+		v.SetBool(b)
+	case reflect.Struct:
+		nested, err := codecValue(valueType, value)
+		if err != nil {
+			return err
+		}
+		return UnmarshalStruct(nested, v.Addr().Interface())
+	case reflect.Ptr:
+		if v.Type().Elem().Kind() != reflect.Struct {
+			return mismatch()
+		}
+		nested, err := codecValue(valueType, value)
+		if err != nil {
+			return err
+		}
+		ptr := reflect.New(v.Type().Elem())
+		if err = UnmarshalStruct(nested, ptr.Interface()); err != nil {
+			return err
+		}
+		v.Set(ptr)
+	case reflect.Slice:
+		if valueType != EasyValueType_LIST {
+			return mismatch()
+		}
+		values := value.([]interface{})
+		slice := reflect.MakeSlice(v.Type(), len(values), len(values))
+		for i, elem := range values {
+			elemType, _ := EasyValueOf(elem)
+			if err := setStructValue(slice.Index(i), elemType, elem); err != nil {
+				return fmt.Errorf("index %d: %w", i, err)
+			}
+		}
+		v.Set(slice)
+	case reflect.Array:
+		if valueType != EasyValueType_LIST {
+			return mismatch()
+		}
+		values := value.([]interface{})
+		if len(values) != v.Len() {
+			return fmt.Errorf("%d elements for %s: %w", len(values), v.Type(), ErrCodec)
+		}
+		for i, elem := range values {
+			elemType, _ := EasyValueOf(elem)
+			if err := setStructValue(v.Index(i), elemType, elem); err != nil {
+				return fmt.Errorf("index %d: %w", i, err)
+			}
+		}
+	default:
+		return mismatch()
+	}
+	return nil
+}
+
+// textValue return STRING, BYTES and DECIMAL values as string
+func textValue(valueType EasyValueType, value interface{}) (string, bool) {
+	switch valueType {
+	case EasyValueType_STRING:
+		return value.(string), true
+	case EasyValueType_BYTES:
+		return string(value.([]byte)), true
+	case EasyValueType_DECIMAL:
+		return string(value.(EasyDecimal)), true
+	}
+	return "", false
+}
+
+// codecValue return CODEC values, and BYTES values holding a serialized EasyCodec
+func codecValue(valueType EasyValueType, value interface{}) (*EasyCodec, error) {
+	switch valueType {
+	case EasyValueType_CODEC:
+		return value.(*EasyCodec), nil
+	case EasyValueType_BYTES:
+		items, err := EasyUnmarshalStrict(value.([]byte))
+		if err != nil {
+			return nil, err
+		}
+		return NewEasyCodecWithItems(items), nil
+	}
+	return nil, fmt.Errorf("can not set value type %d to struct: %w", valueType, ErrCodec)
+}
@@ -0,0 +1,174 @@
+/*
+Copyright (C) BABEC. All rights reserved.
+Copyright (C) THL A29 Limited, a Tencent company. All rights reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package sdk
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+type hash []byte
+
+type structInner struct {
+	N int64 `ec:"n"`
+}
+
+type structAll struct {
+	I32    int32         `ec:"i32"`
+	I      int           `ec:"i"`
+	I8     int8          `ec:"i8"`
+	U      uint64        `ec:"u"`
+	U16    uint16        `ec:"u16"`
+	S      string        `ec:"s,required"`
+	B      []byte        `ec:"b"`
+	Hash   hash          `ec:"hash"`
+	Addr   [4]byte       `ec:"addr"`
+	Ok     bool          `ec:"ok"`
+	D      EasyDecimal   `ec:"d"`
+	Inner  structInner   `ec:"inner"`
+	Ptr    *structInner  `ec:"ptr"`
+	List   []string      `ec:"list"`
+	Pair   [2]int32      `ec:"pair"`
+	Nested []structInner `ec:"nested"`
+	Empty  string        `ec:"empty,omitempty"`
+	Skip   int           `ec:"-"`
+	hidden int
+}
+
+// roundTrip marshal in, serialize and unmarshal it into out
+func roundTrip(t *testing.T, in interface{}, out interface{}) *EasyCodec {
+	t.Helper()
+	ec := NewEasyCodec()
+	if err := ec.MarshalStruct(in); err != nil {
+		t.Fatal(err)
+	}
+	items, err := EasyUnmarshalStrict(ec.Marshal())
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := NewEasyCodecWithItems(items)
+	if err = UnmarshalStruct(decoded, out); err != nil {
+		t.Fatal(err)
+	}
+	return decoded
+}
+
+func TestStructRoundTrip(t *testing.T) {
+	in := structAll{
+		I32: math.MinInt32, I: math.MaxInt64, I8: -8, U: math.MaxUint64, U16: 16,
+		S: "s", B: []byte{0, 1}, Hash: hash{0xff}, Addr: [4]byte{10, 0, 0, 1}, Ok: true, D: "-1.25",
+		Inner: structInner{N: 1}, Ptr: &structInner{N: 2}, List: []string{"x", "y"},
+		Pair: [2]int32{-1, 1}, Nested: []structInner{{N: 3}, {N: 4}}, Skip: 5, hidden: 6,
+	}
+	var out structAll
+	decoded := roundTrip(t, &in, &out)
+	in.Skip, in.hidden = 0, 0
+	if !reflect.DeepEqual(in, out) {
+		t.Fatalf("got %+v\nwant %+v", out, in)
+	}
+	for key, want := range map[string]EasyValueType{
+		"hash": EasyValueType_BYTES, "addr": EasyValueType_BYTES, "pair": EasyValueType_LIST,
+		"inner": EasyValueType_CODEC, "d": EasyValueType_DECIMAL, "i8": EasyValueType_INT64,
+	} {
+		if item, err := decoded.GetItem(key, EasyKeyType_USER); err != nil || item.ValueType != want {
+			t.Errorf("key %s: %v, %v, want value type %d", key, item, err, want)
+		}
+	}
+	for _, key := range []string{"empty", "Skip", "hidden"} {
+		if _, err := decoded.GetItem(key, EasyKeyType_USER); err == nil {
+			t.Errorf("key %s marshaled", key)
+		}
+	}
+}
+
+func TestUnmarshalStructFromArgs(t *testing.T) {
+	args := NewEasyCodecWithMap(map[string][]byte{
+		"i": []byte("-12"), "u16": []byte("7"), "s": []byte("v"), "ok": []byte("true"), "d": []byte("3.5"),
+		"addr": []byte{1, 2, 3, 4}, "hash": []byte("h"),
+	})
+	var out structAll
+	if err := UnmarshalStruct(args, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.I != -12 || out.U16 != 7 || out.S != "v" || !out.Ok || out.D != "3.5" ||
+		out.Addr != [4]byte{1, 2, 3, 4} || string(out.Hash) != "h" {
+		t.Fatalf("got %+v", out)
+	}
+}
+
+func TestUnmarshalStructErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		set  func(ec *EasyCodec)
+	}{
+		{"missing required", func(ec *EasyCodec) { ec.RemoveKey("s") }},
+		{"overflow", func(ec *EasyCodec) { ec.SetInt64("i8", 200) }},
+		{"negative unsigned", func(ec *EasyCodec) { ec.SetInt64("u", -1) }},
+		{"type mismatch", func(ec *EasyCodec) { ec.SetInt64("s", 1) }},
+		{"short array", func(ec *EasyCodec) { ec.SetBytes("addr", []byte{1}) }},
+		{"long list for array", func(ec *EasyCodec) {
+			ec.RemoveKey("pair")
+			_ = ec.AddList("pair", []interface{}{int32(1), int32(2), int32(3)})
+		}},
+		{"bad decimal", func(ec *EasyCodec) { ec.SetString("d", "1e5") }},
+	}
+	for _, tt := range tests {
+		ec := NewEasyCodec()
+		if err := ec.MarshalStruct(structAll{S: "s", D: "0", Pair: [2]int32{1, 2}}); err != nil {
+			t.Fatal(err)
+		}
+		tt.set(ec)
+		var out structAll
+		if err := UnmarshalStruct(ec, &out); !errors.Is(err, ErrCodec) {
+			t.Errorf("%s: got %v, want ErrCodec", tt.name, err)
+		}
+	}
+}
+
+type structNode struct {
+	Name string      `ec:"name"`
+	Next *structNode `ec:"next"`
+}
+
+func TestMarshalStructDepth(t *testing.T) {
+	cyclic := &structNode{Name: "a"}
+	cyclic.Next = cyclic
+	if err := NewEasyCodec().MarshalStruct(cyclic); !errors.Is(err, ErrCodec) {
+		t.Fatalf("cyclic pointer: got %v, want ErrCodec", err)
+	}
+
+	// the innermost of MAX_DEPTH+1 nodes is nested in MAX_DEPTH codecs, as deep as EasyUnmarshal goes
+	var deepest *structNode
+	for i := 0; i <= MAX_DEPTH; i++ {
+		deepest = &structNode{Name: "n", Next: deepest}
+	}
+	var out structNode
+	roundTrip(t, deepest, &out)
+	if err := NewEasyCodec().MarshalStruct(&structNode{Next: deepest}); !errors.Is(err, ErrCodec) {
+		t.Fatalf("over MAX_DEPTH: got %v, want ErrCodec", err)
+	}
+	if err := NewEasyCodec().MarshalStruct(nil); !errors.Is(err, ErrCodec) {
+		t.Fatalf("nil: got %v", err)
+	}
+}
+
+func TestMarshalStructNilRequired(t *testing.T) {
+	type withRequired struct {
+		Ptr *structInner `ec:"ptr,required"`
+	}
+	if err := NewEasyCodec().MarshalStruct(withRequired{}); !errors.Is(err, ErrCodec) {
+		t.Fatalf("nil required field: got %v, want ErrCodec", err)
+	}
+	var out withRequired
+	roundTrip(t, withRequired{Ptr: &structInner{N: 3}}, &out)
+	if out.Ptr == nil || out.Ptr.N != 3 {
+		t.Fatalf("got %+v", out.Ptr)
+	}
+}
@@ -0,0 +1,655 @@
+/*
+Copyright (C) BABEC. All rights reserved.
+Copyright (C) THL A29 Limited, a Tencent company. All rights reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package sdk
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestEasyCodecValueTypes(t *testing.T) {
+	nested := NewEasyCodec()
+	nested.AddString("s", "x")
+	nested.AddInt64("i", -1)
+
+	tests := []struct {
+		name string
+		add  func(ec *EasyCodec)
+		get  func(ec *EasyCodec) (interface{}, error)
+		want interface{}
+	}{
+		{
+			name: "int32",
+			add:  func(ec *EasyCodec) { ec.AddInt32("v", math.MinInt32) },
+			get:  func(ec *EasyCodec) (interface{}, error) { return ec.GetInt32("v") },
+			want: int32(math.MinInt32),
+		},
+		{
+			name: "string",
+			add:  func(ec *EasyCodec) { ec.AddString("v", "a\"b\\c\x00\xff") },
+			get:  func(ec *EasyCodec) (interface{}, error) { return ec.GetString("v") },
+			want: "a\"b\\c\x00\xff",
+		},
+		{
+			name: "empty string",
+			add:  func(ec *EasyCodec) { ec.AddString("", "") },
+			get:  func(ec *EasyCodec) (interface{}, error) { return ec.GetString("") },
+			want: "",
+		},
+		{
+			name: "bytes",
+			add:  func(ec *EasyCodec) { ec.AddBytes("v", []byte{0, 1, 255}) },
+			get:  func(ec *EasyCodec) (interface{}, error) { return ec.GetBytes("v") },
+			want: []byte{0, 1, 255},
+		},
+		{
+			name: "int64",
+			add:  func(ec *EasyCodec) { ec.AddInt64("v", math.MinInt64) },
+			get:  func(ec *EasyCodec) (interface{}, error) { return ec.GetInt64("v") },
+			want: int64(math.MinInt64),
+		},
+		{
+			name: "uint64",
+			add:  func(ec *EasyCodec) { ec.AddUint64("v", math.MaxUint64) },
+			get:  func(ec *EasyCodec) (interface{}, error) { return ec.GetUint64("v") },
+			want: uint64(math.MaxUint64),
+		},
+		{
+			name: "bool",
+			add:  func(ec *EasyCodec) { ec.AddBool("v", true) },
+			get:  func(ec *EasyCodec) (interface{}, error) { return ec.GetBool("v") },
+			want: true,
+		},
+		{
+			name: "decimal",
+			add:  func(ec *EasyCodec) { ec.AddDecimal("v", "-12.340") },
+			get:  func(ec *EasyCodec) (interface{}, error) { return ec.GetDecimal("v") },
+			want: EasyDecimal("-12.340"),
+		},
+		{
+			name: "codec",
+			add:  func(ec *EasyCodec) { ec.AddCodec("v", nested) },
+			get: func(ec *EasyCodec) (interface{}, error) {
+				c, err := ec.GetCodec("v")
+				if err != nil {
+					return nil, err
+				}
+				return c.Marshal(), nil
+			},
+			want: nested.Marshal(),
+		},
+		{
+			name: "list",
+			add: func(ec *EasyCodec) {
+				_ = ec.AddList("v", []interface{}{int32(1), "s", []byte{2}, int64(-3), uint64(4), false,
+					EasyDecimal("5.5"), []interface{}{"nested"}})
+			},
+			get: func(ec *EasyCodec) (interface{}, error) { return ec.GetList("v") },
+			want: []interface{}{int32(1), "s", []byte{2}, int64(-3), uint64(4), false,
+				EasyDecimal("5.5"), []interface{}{"nested"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ec := NewEasyCodec()
+			tt.add(ec)
+			for _, data := range [][]byte{ec.Marshal(), ec.MarshalWithHeader()} {
+				got, err := tt.get(NewEasyCodecWithBytes(data))
+				if err != nil || !reflect.DeepEqual(got, tt.want) {
+					t.Fatalf("EasyUnmarshal got %#v, %v, want %#v", got, err, tt.want)
+				}
+				items, err := EasyUnmarshalStrict(data)
+				if err != nil {
+					t.Fatalf("EasyUnmarshalStrict: %v", err)
+				}
+				got, err = tt.get(NewEasyCodecWithItems(items))
+				if err != nil || !reflect.DeepEqual(got, tt.want) {
+					t.Fatalf("EasyUnmarshalStrict got %#v, %v, want %#v", got, err, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestEasyUnmarshalStrictErrors(t *testing.T) {
+	valid := NewEasyCodec()
+	valid.AddString("k", "v")
+	data := valid.Marshal()
+
+	badVersion := valid.MarshalWithHeader()
+	copy(badVersion[EC_MAGIC_NUM_LEN:], "v9.9")
+
+	// AddCodec rejects this nesting, so it is built from raw items
+	deep := item(EasyKeyType_USER, "leaf", EasyValueType_INT32, []byte{1, 0, 0, 0})
+	for i := 0; i <= MAX_DEPTH; i++ {
+		deep = item(EasyKeyType_USER, "n", EasyValueType_CODEC, deep)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"truncated count", data[:3]},
+		{"truncated item", data[:len(data)-1]},
+		{"trailing bytes", append(append([]byte{}, data...), 0)},
+		{"bad version", badVersion},
+		{"truncated header", valid.MarshalWithHeader()[:10]},
+		{"count over limit", []byte{MAX_KEY_COUNT + 1, 0, 0, 0}},
+		{"unknown key type", item(7, "k", EasyValueType_STRING, nil)},
+		{"key over limit", item(EasyKeyType_USER, string(make([]byte, MAX_KEY_LEN+1)), EasyValueType_STRING, nil)},
+		{"unknown value type", item(EasyKeyType_USER, "k", 99, nil)},
+		{"short int32", item(EasyKeyType_USER, "k", EasyValueType_INT32, []byte{1})},
+		{"long int64", item(EasyKeyType_USER, "k", EasyValueType_INT64, make([]byte, 9))},
+		{"bool 2", item(EasyKeyType_USER, "k", EasyValueType_BOOL, []byte{2})},
+		{"bad decimal", item(EasyKeyType_USER, "k", EasyValueType_DECIMAL, []byte("1."))},
+		{"decimal leading zero", item(EasyKeyType_USER, "k", EasyValueType_DECIMAL, []byte("01.5"))},
+		{"bad nested codec", item(EasyKeyType_USER, "k", EasyValueType_CODEC, []byte{1, 0, 0, 0})},
+		{"list count over data", item(EasyKeyType_USER, "k", EasyValueType_LIST, []byte{2, 0, 0, 0})},
+		{"list trailing bytes", item(EasyKeyType_USER, "k", EasyValueType_LIST, append(bytesList([]byte("a")), 0))},
+		{"too deep", deep},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if items, err := EasyUnmarshalStrict(tt.data); !errors.Is(err, ErrCodec) {
+				t.Fatalf("got %v, %v, want ErrCodec", items, err)
+			}
+			var s EasyScanner
+			s.Reset(tt.data)
+			for s.Next() {
+			}
+			// the scanner does not decode values, only the framing errors are expected
+			if tt.name == "empty" || tt.name == "trailing bytes" || tt.name == "bad version" {
+				if !errors.Is(s.Err(), ErrCodec) {
+					t.Fatalf("scanner got %v, want ErrCodec", s.Err())
+				}
+			}
+		})
+	}
+}
+
+func TestEasyUnmarshalStrictDoesNotAlias(t *testing.T) {
+	data := append(item(EasyKeyType_USER, "k", EasyValueType_LIST, bytesList([]byte("a"))),
+		item(EasyKeyType_USER, "b", EasyValueType_BYTES, []byte("b"))[4:]...)
+	data[0] = 2
+	items, err := EasyUnmarshalStrict(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range data {
+		data[i] = 'x'
+	}
+	ec := NewEasyCodecWithItems(items)
+	list, _ := ec.GetList("k")
+	value, _ := ec.GetBytes("b")
+	if len(list) != 1 || !bytes.Equal(list[0].([]byte), []byte("a")) || !bytes.Equal(value, []byte("b")) {
+		t.Fatalf("got %q, %q after changing the input", list, value)
+	}
+}
+
+// bytesList serialize a LIST value of BYTES elements
+func bytesList(elems ...[]byte) []byte {
+	b := appendUint32(nil, uint32(len(elems)))
+	for _, elem := range elems {
+		b = appendUint32(b, uint32(EasyValueType_BYTES))
+		b = appendUint32(b, uint32(len(elem)))
+		b = append(b, elem...)
+	}
+	return b
+}
+
+func TestEasyDecimalChecked(t *testing.T) {
+	for _, s := range []string{"0", "-0", "0.5", "10", "-12.340"} {
+		if _, err := ParseEasyDecimal(s); err != nil {
+			t.Fatalf("%q: %v", s, err)
+		}
+	}
+	injected := EasyDecimal(`1","x":"`)
+	for _, d := range []EasyDecimal{"", "-", "1.", ".5", "01.5", "00", "1e5", "+1", injected} {
+		if _, err := ParseEasyDecimal(string(d)); !errors.Is(err, ErrCodec) {
+			t.Fatalf("%q: got %v, want ErrCodec", d, err)
+		}
+		ec := NewEasyCodec()
+		if err := ec.AddDecimal("k", d); !errors.Is(err, ErrCodec) {
+			t.Fatalf("AddDecimal %q: got %v, want ErrCodec", d, err)
+		}
+		if err := ec.SetDecimal("k", d); !errors.Is(err, ErrCodec) {
+			t.Fatalf("SetDecimal %q: got %v, want ErrCodec", d, err)
+		}
+		if err := ec.AddList("k", []interface{}{[]interface{}{d}}); !errors.Is(err, ErrCodec) {
+			t.Fatalf("AddList %q: got %v, want ErrCodec", d, err)
+		}
+		if len(ec.GetItems()) != 0 {
+			t.Fatalf("%q was added", d)
+		}
+	}
+
+	// values added unchecked are still written as json strings
+	ec := NewEasyCodec()
+	ec.AddValue(EasyKeyType_USER, "k", EasyValueType_DECIMAL, injected)
+	var m map[string]string
+	if err := json.Unmarshal([]byte(ec.ToJson()), &m); err != nil || len(m) != 1 || m["k"] != string(injected) {
+		t.Fatalf("ToJson %s: %v, %v", ec.ToJson(), m, err)
+	}
+	if _, err := ec.MarshalJSON(); !errors.Is(err, ErrCodec) {
+		t.Fatalf("MarshalJSON got %v, want ErrCodec", err)
+	}
+}
+
+// item serialize a single raw item, without any check
+func item(keyType EasyKeyType, key string, valueType EasyValueType, val []byte) []byte {
+	b := AppendEasyCount(nil, 1)
+	b = appendEasyItemHead(b, keyType, key, valueType, len(val))
+	return append(b, val...)
+}
+
+func TestEasyCodecNesting(t *testing.T) {
+	ec := NewEasyCodec()
+	if err := ec.AddCodec("self", ec); !errors.Is(err, ErrCodec) || len(ec.GetItems()) != 0 {
+		t.Fatalf("codec in itself: %v", err)
+	}
+	if err := ec.SetCodec("nil", nil); !errors.Is(err, ErrCodec) {
+		t.Fatalf("nil codec: %v", err)
+	}
+
+	deep := NewEasyCodec()
+	deep.AddInt32("leaf", 1)
+	for i := 0; i < MAX_DEPTH; i++ {
+		outer := NewEasyCodec()
+		if err := outer.AddCodec("n", deep); err != nil {
+			t.Fatalf("nesting %d: %v", i+1, err)
+		}
+		deep = outer
+	}
+	if _, err := EasyUnmarshalStrict(deep.Marshal()); err != nil {
+		t.Fatalf("the deepest accepted nesting does not decode: %v", err)
+	}
+	if err := NewEasyCodec().AddCodec("n", deep); !errors.Is(err, ErrCodec) {
+		t.Fatalf("over MAX_DEPTH: %v", err)
+	}
+
+	list := []interface{}{int32(1)}
+	for i := 0; i < MAX_DEPTH; i++ {
+		list = []interface{}{list}
+	}
+	if err := NewEasyCodec().AddList("l", list); !errors.Is(err, ErrCodec) {
+		t.Fatalf("list over MAX_DEPTH: %v", err)
+	}
+	cyclic := []interface{}{nil}
+	cyclic[0] = cyclic
+	if err := NewEasyCodec().AddList("l", cyclic); !errors.Is(err, ErrCodec) {
+		t.Fatalf("list in itself: %v", err)
+	}
+	if err := NewEasyCodec().AddList("l", []interface{}{1.5}); !errors.Is(err, ErrCodec) {
+		t.Fatalf("unsupported element: %v", err)
+	}
+}
+
+func TestEasyCodecUncheckedCyclePanics(t *testing.T) {
+	outer, inner := NewEasyCodec(), NewEasyCodec()
+	if err := outer.AddCodec("inner", inner); err != nil {
+		t.Fatal(err)
+	}
+	if err := inner.AddCodec("outer", outer); !errors.Is(err, ErrCodec) {
+		t.Fatalf("cycle through AddCodec: %v", err)
+	}
+	// AddValue does not check the nesting
+	inner.AddValue(EasyKeyType_USER, "outer", EasyValueType_CODEC, outer)
+	defer func() {
+		if err, _ := recover().(error); !errors.Is(err, ErrCodec) {
+			t.Fatalf("recovered %v, want ErrCodec", err)
+		}
+	}()
+	outer.Marshal()
+}
+
+// keys return keyType:key of the items of ec, in order
+func keys(ec *EasyCodec) string {
+	var b strings.Builder
+	for _, item := range ec.GetItems() {
+		fmt.Fprintf(&b, "%d:%s,", item.KeyType, item.Key)
+	}
+	return b.String()
+}
+
+func TestEasyDupPolicies(t *testing.T) {
+	for _, indexed := range []bool{false, true} {
+		ec := NewEasyCodec()
+		if indexed {
+			ec.EnableIndex()
+		}
+		ec.AddString("a", "1")
+		ec.AddString("a", "2")
+		ec.AddValue(EasyKeyType_SYSTEM, "a", EasyValueType_STRING, "sys")
+		if n := len(ec.GetAll("a")); n != 2 {
+			t.Fatalf("indexed %v: keep all got %d user items", indexed, n)
+		}
+
+		ec.SetDupPolicy(EasyDupPolicy_OVERWRITE)
+		ec.AddInt32("a", 3)
+		if v, _ := ec.GetInt32("a"); v != 3 || keys(ec) != "1:a,1:a,0:a," {
+			t.Fatalf("indexed %v: overwrite got %d, %s", indexed, v, keys(ec))
+		}
+
+		ec.SetDupPolicy(EasyDupPolicy_REJECT)
+		if ec.Err() != nil {
+			t.Fatalf("indexed %v: error before a rejected key: %v", indexed, ec.Err())
+		}
+		ec.AddString("b", "1")
+		ec.AddString("b", "2")
+		ec.AddString("a", "4")
+		ec.AddValue(EasyKeyType_SYSTEM, "b", EasyValueType_STRING, "other key type")
+		if err := ec.Err(); !errors.Is(err, ErrDuplicateKey) || !strings.Contains(err.Error(), `"b"`) {
+			t.Fatalf("indexed %v: Err got %v, want the first rejected key b", indexed, err)
+		}
+		if v, _ := ec.GetString("b"); v != "1" || keys(ec) != "1:a,1:a,0:a,1:b,0:b," {
+			t.Fatalf("indexed %v: reject got %s, %s", indexed, v, keys(ec))
+		}
+	}
+}
+
+func TestEasyIndexFollowsChanges(t *testing.T) {
+	ec := NewEasyCodec()
+	ec.AddString("a", "1")
+	ec.AddString("b", "1")
+	ec.EnableIndex()
+	ec.AddString("a", "2")
+	ec.AddValue(EasyKeyType_SYSTEM, "a", EasyValueType_STRING, "sys")
+	ec.SetString("b", "2")
+	ec.SetString("c", "3")
+	if all := ec.GetAll("a"); len(all) != 2 || all[1].Value != "2" {
+		t.Fatalf("GetAll after EnableIndex %v", all)
+	}
+
+	ec.SetString("a", "one")
+	if all := ec.GetAll("a"); len(all) != 1 || all[0].Value != "one" || keys(ec) != "1:a,1:b,0:a,1:c," {
+		t.Fatalf("Set kept %v, items %s", all, keys(ec))
+	}
+	ec.RemoveKey("a")
+	if _, err := ec.GetString("a"); err == nil || keys(ec) != "1:b,0:a,1:c," {
+		t.Fatalf("RemoveKey left %s", keys(ec))
+	}
+	if _, err := ec.GetItem("a", EasyKeyType_SYSTEM); err != nil {
+		t.Fatal("RemoveKey removed the system item")
+	}
+	ec.RemoveValue(EasyKeyType_SYSTEM, "a")
+	ec.RemoveKey("missing")
+	if _, err := ec.GetItem("a", EasyKeyType_SYSTEM); err == nil || keys(ec) != "1:b,1:c," {
+		t.Fatalf("RemoveValue left %s", keys(ec))
+	}
+	if v, _ := ec.GetString("c"); v != "3" {
+		t.Fatalf("lookup after removals got %q", v)
+	}
+}
+
+func TestEasyCodecRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		ec := NewEasyCodec()
+		for n := r.Intn(12); n > 0; n-- {
+			valueType, value := randomEasyValue(r, 0)
+			ec.AddValue(EasyKeyType(r.Intn(2)), randomString(r, MAX_KEY_LEN), valueType, value)
+		}
+		plain, withHeader := ec.Marshal(), ec.MarshalWithHeader()
+		if !bytes.Equal(withHeader[EC_HEADER_LEN:], plain) {
+			t.Fatalf("header form differs beyond the header")
+		}
+		for _, data := range [][]byte{plain, withHeader} {
+			if got := EasyMarshal(EasyUnmarshal(data)); !bytes.Equal(got, plain) {
+				t.Fatalf("EasyUnmarshal round trip of %x gives %x", data, got)
+			}
+			items, err := EasyUnmarshalStrict(data)
+			if err != nil {
+				t.Fatalf("EasyUnmarshalStrict(%x): %v", data, err)
+			}
+			if got := EasyMarshal(items); !bytes.Equal(got, plain) {
+				t.Fatalf("EasyUnmarshalStrict round trip of %x gives %x", data, got)
+			}
+			if got := EasyMarshalWithHeader(items); !bytes.Equal(got, withHeader) {
+				t.Fatalf("header round trip of %x gives %x", data, got)
+			}
+		}
+		var back EasyCodec
+		j, err := ec.MarshalJSON()
+		if err == nil {
+			err = back.UnmarshalJSON(j)
+		}
+		if err != nil || !bytes.Equal(back.Marshal(), plain) {
+			t.Fatalf("json round trip of %s: %v", j, err)
+		}
+	}
+}
+
+func TestEasyCodecJSONRejectsInvalidUTF8(t *testing.T) {
+	invalid := string([]byte{'a', 0xff})
+	nested := NewEasyCodec()
+	nested.AddString("s", invalid)
+	for name, ec := range map[string]*EasyCodec{
+		"key":    NewEasyCodecWithMap(map[string][]byte{invalid: []byte("v")}),
+		"value":  NewEasyCodecWithItems([]*EasyCodecItem{newEasyCodecItem(EasyKeyType_USER, "k", EasyValueType_STRING, invalid)}),
+		"nested": NewEasyCodecWithItems([]*EasyCodecItem{newEasyCodecItem(EasyKeyType_USER, "k", EasyValueType_CODEC, nested)}),
+		"list":   NewEasyCodecWithItems([]*EasyCodecItem{newEasyCodecItem(EasyKeyType_USER, "k", EasyValueType_LIST, []interface{}{invalid})}),
+	} {
+		if _, err := ec.MarshalJSON(); !errors.Is(err, ErrCodec) {
+			t.Errorf("%s: got %v, want ErrCodec", name, err)
+		}
+	}
+
+	// bytes are base64, any byte goes through
+	ec := NewEasyCodec()
+	ec.AddBytes("b", []byte{0xff, 0xfe})
+	ec.AddString("s", "\u2028 é")
+	j, err := ec.MarshalJSON()
+	var back EasyCodec
+	if err == nil {
+		err = back.UnmarshalJSON(j)
+	}
+	if err != nil || !bytes.Equal(back.Marshal(), ec.Marshal()) {
+		t.Fatalf("json round trip of %s: %v", j, err)
+	}
+}
+
+func randomString(r *rand.Rand, max int) string {
+	b := make([]byte, r.Intn(max+1))
+	for i := range b {
+		b[i] = byte(' ' + r.Intn(95))
+	}
+	return string(b)
+}
+
+func randomEasyValue(r *rand.Rand, depth int) (EasyValueType, interface{}) {
+	n := int(EasyValueType_LIST) + 1
+	if depth >= 2 {
+		n = int(EasyValueType_DECIMAL) + 1
+	}
+	switch valueType := EasyValueType(r.Intn(n)); valueType {
+	case EasyValueType_INT32:
+		return valueType, int32(r.Uint32())
+	case EasyValueType_STRING:
+		return valueType, randomString(r, 32)
+	case EasyValueType_BYTES:
+		b := make([]byte, r.Intn(32))
+		r.Read(b)
+		return valueType, b
+	case EasyValueType_INT64:
+		return valueType, int64(r.Uint64())
+	case EasyValueType_UINT64:
+		return valueType, r.Uint64()
+	case EasyValueType_BOOL:
+		return valueType, r.Intn(2) == 1
+	case EasyValueType_DECIMAL:
+		return valueType, EasyDecimal([]string{"0", "-1", "12.340", "-0.5"}[r.Intn(4)])
+	case EasyValueType_CODEC:
+		nested := NewEasyCodec()
+		for n := r.Intn(4); n > 0; n-- {
+			elemType, elem := randomEasyValue(r, depth+1)
+			nested.AddValue(EasyKeyType_USER, randomString(r, 8), elemType, elem)
+		}
+		return valueType, nested
+	default:
+		values := make([]interface{}, r.Intn(4))
+		for i := range values {
+			_, values[i] = randomEasyValue(r, depth+1)
+		}
+		return EasyValueType_LIST, values
+	}
+}
+
+func easyFuzzSeeds(f *testing.F) {
+	r := rand.New(rand.NewSource(2))
+	for i := 0; i < 16; i++ {
+		ec := NewEasyCodec()
+		for n := r.Intn(6); n > 0; n-- {
+			valueType, value := randomEasyValue(r, 0)
+			ec.AddValue(EasyKeyType_USER, randomString(r, 8), valueType, value)
+		}
+		f.Add(ec.Marshal())
+		f.Add(ec.MarshalWithHeader())
+	}
+	f.Add([]byte{})
+	f.Add([]byte("cmec"))
+	f.Add([]byte{MAX_KEY_COUNT, 0, 0, 0})
+}
+
+// allocated return the bytes allocated by fn
+func allocated(fn func()) uint64 {
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	fn()
+	runtime.ReadMemStats(&after)
+	return after.TotalAlloc - before.TotalAlloc
+}
+
+// maxAllocated the allocation allowed to decode data, in proportion to its length
+func maxAllocated(data []byte) uint64 {
+	return 64*1024 + 256*uint64(len(data))
+}
+
+func FuzzEasyUnmarshal(f *testing.F) {
+	easyFuzzSeeds(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var items []*EasyCodecItem
+		if n := allocated(func() { items = EasyUnmarshal(data) }); n > maxAllocated(data) {
+			t.Fatalf("%d bytes allocated for %d bytes of input", n, len(data))
+		}
+		if len(items) > MAX_KEY_COUNT {
+			t.Fatalf("%d items over MAX_KEY_COUNT", len(items))
+		}
+		// whatever is decoded can be serialized again
+		EasyMarshal(items)
+	})
+}
+
+func FuzzEasyUnmarshalStrict(f *testing.F) {
+	easyFuzzSeeds(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var (
+			items []*EasyCodecItem
+			err   error
+		)
+		if n := allocated(func() { items, err = EasyUnmarshalStrict(data) }); n > maxAllocated(data) {
+			t.Fatalf("%d bytes allocated for %d bytes of input", n, len(data))
+		}
+		if err != nil {
+			if !errors.Is(err, ErrCodec) {
+				t.Fatalf("error %v does not wrap ErrCodec", err)
+			}
+			return
+		}
+		// accepted input is exactly the serialization of its items
+		_, body, _ := ParseEasyHeader(data)
+		if got := EasyMarshal(items); !bytes.Equal(got, body) {
+			t.Fatalf("%x decoded then serialized gives %x", body, got)
+		}
+		if lenient := EasyMarshal(EasyUnmarshal(data)); !bytes.Equal(lenient, body) {
+			t.Fatalf("EasyUnmarshal disagrees on %x: %x", body, lenient)
+		}
+	})
+}
+
+func FuzzEasyScanner(f *testing.F) {
+	easyFuzzSeeds(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var s EasyScanner
+		s.Reset(data)
+		n := 0
+		for s.Next() {
+			n++
+			if n > MAX_KEY_COUNT {
+				t.Fatalf("%d items over MAX_KEY_COUNT", n)
+			}
+		}
+		items, err := EasyUnmarshalStrict(data)
+		if err == nil && (s.Err() != nil || n != len(items)) {
+			t.Fatalf("scanner got %d items, %v, EasyUnmarshalStrict %d items", n, s.Err(), len(items))
+		}
+	})
+}
+
+func validUTF8Items(items []*EasyCodecItem) bool {
+	for _, item := range items {
+		if !utf8.ValidString(item.Key) || !validUTF8Value(item.Value) {
+			return false
+		}
+	}
+	return true
+}
+
+func validUTF8Value(value interface{}) bool {
+	switch v := value.(type) {
+	case string:
+		return utf8.ValidString(v)
+	case *EasyCodec:
+		return validUTF8Items(v.items)
+	case []interface{}:
+		for _, elem := range v {
+			if !validUTF8Value(elem) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func FuzzEasyCodecJSON(f *testing.F) {
+	easyFuzzSeeds(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		items, err := EasyUnmarshalStrict(data)
+		if err != nil {
+			return
+		}
+		ec := NewEasyCodecWithItems(items)
+		j, err := ec.MarshalJSON()
+		if !validUTF8Items(items) {
+			// json strings can not hold invalid utf8
+			if !errors.Is(err, ErrCodec) {
+				t.Fatalf("MarshalJSON of invalid utf8 %x: got %v, want ErrCodec", data, err)
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("MarshalJSON: %v", err)
+		}
+		var back EasyCodec
+		if err = back.UnmarshalJSON(j); err != nil {
+			t.Fatalf("UnmarshalJSON(%s): %v", j, err)
+		}
+		if !bytes.Equal(back.Marshal(), ec.Marshal()) {
+			t.Fatalf("json round trip of %x changed it", data)
+		}
+	})
+}
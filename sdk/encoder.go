@@ -0,0 +1,116 @@
+/*
+Copyright (C) BABEC. All rights reserved.
+Copyright (C) THL A29 Limited, a Tencent company. All rights reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package sdk
+
+// Encoder serialize items straight into a reusable buffer, in the layout of EasyMarshal without
+// header, with no EasyCodecItem nor interface{} per value:
+//
+//	enc := sdk.NewEncoder(256)
+//	enc.AddString("key", key)
+//	enc.AddBytes("value", value)
+//	payload := enc.Bytes()
+//	...
+//	enc.Reset()
+//
+// Items are written in order, duplicates are kept
+type Encoder struct {
+	buf   []byte
+	count int
+}
+
+// NewEncoder return an Encoder with room for size bytes of items
+func NewEncoder(size int) *Encoder {
+	return &Encoder{buf: make([]byte, 4, 4+size)}
+}
+
+// Reset drop the items, keeping the buffer. The slices returned by Bytes are overwritten afterwards
+func (enc *Encoder) Reset() {
+	if enc.buf == nil {
+		enc.buf = make([]byte, 4)
+	}
+	enc.buf = enc.buf[:4]
+	enc.count = 0
+}
+
+// Bytes return the serialization of the items, valid until the next Add or Reset
+func (enc *Encoder) Bytes() []byte {
+	if enc.buf == nil {
+		enc.Reset()
+	}
+	n := uint32(enc.count)
+	enc.buf[0], enc.buf[1], enc.buf[2], enc.buf[3] = byte(n), byte(n>>8), byte(n>>16), byte(n>>24)
+	return enc.buf
+}
+
+// Len return the number of items
+func (enc *Encoder) Len() int {
+	return enc.count
+}
+
+func (enc *Encoder) grow() {
+	if enc.buf == nil {
+		enc.Reset()
+	}
+	enc.count++
+}
+
+func (enc *Encoder) AddInt32(key string, value int32) {
+	enc.grow()
+	enc.buf = AppendEasyInt32(enc.buf, key, value)
+}
+
+func (enc *Encoder) AddString(key string, value string) {
+	enc.grow()
+	enc.buf = AppendEasyString(enc.buf, key, value)
+}
+
+func (enc *Encoder) AddBytes(key string, value []byte) {
+	enc.grow()
+	enc.buf = AppendEasyBytes(enc.buf, key, value)
+}
+
+func (enc *Encoder) AddInt64(key string, value int64) {
+	enc.grow()
+	enc.buf = AppendEasyInt64(enc.buf, key, value)
+}
+
+func (enc *Encoder) AddUint64(key string, value uint64) {
+	enc.grow()
+	enc.buf = AppendEasyUint64(enc.buf, key, value)
+}
+
+func (enc *Encoder) AddBool(key string, value bool) {
+	enc.grow()
+	enc.buf = AppendEasyBool(enc.buf, key, value)
+}
+
+// AddDecimal add a decimal, an invalid one is ErrCodec and nothing is added
+func (enc *Encoder) AddDecimal(key string, value EasyDecimal) error {
+	if _, err := ParseEasyDecimal(string(value)); err != nil {
+		return err
+	}
+	enc.grow()
+	enc.buf = AppendEasyDecimal(enc.buf, key, value)
+	return nil
+}
+
+// AddCodec add a nested serialization without header, as the Bytes of another Encoder
+func (enc *Encoder) AddCodec(key string, value []byte) {
+	enc.grow()
+	enc.buf = AppendEasyCodec(enc.buf, key, value)
+}
+
+func (enc *Encoder) addSystemInt32(key string, value int32) {
+	enc.grow()
+	enc.buf = appendUint32(appendEasyItemHead(enc.buf, EasyKeyType_SYSTEM, key, EasyValueType_INT32, 4), uint32(value))
+}
+
+func (enc *Encoder) addSystemString(key string, value string) {
+	enc.grow()
+	enc.buf = append(appendEasyItemHead(enc.buf, EasyKeyType_SYSTEM, key, EasyValueType_STRING, len(value)), value...)
+}
@@ -0,0 +1,13 @@
+/*
+Copyright (C) BABEC. All rights reserved.
+Copyright (C) THL A29 Limited, a Tencent company. All rights reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package entry exports the invoke_contract, init_contract and upgrade entries of a contract
+// served by sdk.Serve. Contracts hand-writing their exports must not import it. invoke_contract
+// needs a host passing the method name in the "__method__" arg, see sdk.InvokeContract.
+//
+//	import _ "github.com/TKOTKCh/contract-sdk-go-wasm/sdk/entry"
+package entry
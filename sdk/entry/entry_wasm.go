@@ -0,0 +1,28 @@
+//go:build wasm
+// +build wasm
+
+/*
+Copyright (C) BABEC. All rights reserved.
+Copyright (C) THL A29 Limited, a Tencent company. All rights reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package entry
+
+import "github.com/TKOTKCh/contract-sdk-go-wasm/sdk"
+
+//go:wasmexport invoke_contract
+func invokeContract() {
+	sdk.InvokeContract()
+}
+
+//go:wasmexport init_contract
+func initContract() {
+	sdk.Dispatch(sdk.MethodInitContract)
+}
+
+//go:wasmexport upgrade
+func upgrade() {
+	sdk.Dispatch(sdk.MethodUpgrade)
+}
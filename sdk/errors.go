@@ -0,0 +1,63 @@
+/*
+Copyright (C) BABEC. All rights reserved.
+Copyright (C) THL A29 Limited, a Tencent company. All rights reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package sdk
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNotFound the requested arg, key or item does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrCodec data can not be serialized or deserialized
+	ErrCodec = errors.New("codec error")
+	// ErrLimitExceeded a request is over one of the limits of the sdk or the chain
+	ErrLimitExceeded = errors.New("limit exceeded")
+	// ErrDuplicateKey a key is already present, see EasyDupPolicy_REJECT and collections.IndexedMap
+	ErrDuplicateKey = errors.New("duplicate key")
+	// ErrInvalidArgument an argument is out of the values a function accepts
+	ErrInvalidArgument = errors.New("invalid argument")
+	// ErrUnsupported the host does not support the request
+	ErrUnsupported = errors.New("unsupported")
+	// ErrIterator an iterator implemented outside of the sdk failed, see ForEachKV
+	ErrIterator = errors.New("iterator failed")
+)
+
+// ErrHostCall a sys_call returned a non-zero code, as:
+//
+//	var hostErr *sdk.ErrHostCall
+//	if errors.As(err, &hostErr) {
+//		ctx.Errorf("%s failed with code %d", hostErr.Method, hostErr.Code)
+//	}
+type ErrHostCall struct {
+	// Method the sys_call method, as ContractMethodGetStateLen
+	Method string
+	// Code the code returned by the host
+	Code int32
+}
+
+func (e *ErrHostCall) Error() string {
+	return fmt.Sprintf("sys_call %s failed with code %d", e.Method, e.Code)
+}
+
+// hostCall send body to method, a non-zero code is returned as *ErrHostCall
+func hostCall(method string, body []byte) error {
+	if code := sysCall(getRequestHeader(method), bytesToString(body)); code != int32(SUCCESS) {
+		return &ErrHostCall{Method: method, Code: code}
+	}
+	return nil
+}
+
+// resultCode convert err to the ResultCode of the legacy api
+func resultCode(err error) ResultCode {
+	if err != nil {
+		return ERROR
+	}
+	return SUCCESS
+}
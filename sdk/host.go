@@ -0,0 +1,42 @@
+/*
+Copyright (C) BABEC. All rights reserved.
+Copyright (C) THL A29 Limited, a Tencent company. All rights reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package sdk
+
+import "unsafe"
+
+// Host is the contract VM as seen by the sdk. Every chain interaction goes through it:
+// inside the wasm VM it is backed by the env imports, in native builds it can be replaced
+// by SetHost so contracts can be exercised by go test.
+type Host interface {
+	// SysCall send requestHeader and requestBody to the chain, the return value is the result code.
+	// requestBody may share memory with a buffer the sdk reuses for the next sys_call,
+	// the host must copy what it needs and must not retain requestBody after SysCall returns
+	SysCall(requestHeader string, requestBody string) int32
+	// Log record log to chain server
+	Log(msg string)
+	// LogWithType record log with level to chain server
+	LogWithType(msg string, msgType int32)
+}
+
+func logMessage(msg string) {
+	host.Log(msg)
+}
+
+func logMessageWithType(msg string, msgType int32) {
+	host.LogWithType(msg, msgType)
+}
+
+// int32Ptr return the value_ptr of v, the host writes a le int32 back through it
+func int32Ptr(v *int32) int32 {
+	return ptrOf((*[4]byte)(unsafe.Pointer(v))[:])
+}
+
+// bytesToString return b as a string without copying, b must not change while the string is used
+func bytesToString(b []byte) string {
+	return *(*string)(unsafe.Pointer(&b))
+}
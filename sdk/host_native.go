@@ -0,0 +1,70 @@
+//go:build !wasm
+// +build !wasm
+
+/*
+Copyright (C) BABEC. All rights reserved.
+Copyright (C) THL A29 Limited, a Tencent company. All rights reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package sdk
+
+var host Host = unsetHost{}
+
+// SetHost replace the Host used by the sdk and return the previous one, only available in native builds
+func SetHost(h Host) Host {
+	if h == nil {
+		h = unsetHost{}
+	}
+	previous := host
+	host = h
+	return previous
+}
+
+// SetArgs set the serialized args of the current call, as the VM does through allocate
+func SetArgs(data []byte) {
+	argsBytes = append([]byte(nil), data...)
+	resetArgs()
+}
+
+// memory shared with the host during one sys_call, native pointers do not fit the int32 value_ptr
+// so the sdk hands out handles instead
+var (
+	hostMemory    = make(map[int32][]byte)
+	hostMemoryPtr int32
+)
+
+// HostMemory return the buffer behind a pointer passed to Host.SysCall, as value_ptr, nil if unknown.
+// It is only valid until SysCall returns, handles are never reused
+func HostMemory(ptr int32) []byte {
+	return hostMemory[ptr]
+}
+
+func ptrOf(b []byte) int32 {
+	hostMemoryPtr++
+	hostMemory[hostMemoryPtr] = b
+	return hostMemoryPtr
+}
+
+func sysCall(requestHeader string, requestBody string) int32 {
+	defer func() {
+		hostMemory = make(map[int32][]byte)
+	}()
+	return host.SysCall(requestHeader, requestBody)
+}
+
+// unsetHost is the default Host of native builds
+type unsetHost struct{}
+
+func (unsetHost) SysCall(requestHeader string, requestBody string) int32 {
+	panic("sdk: no Host set, call sdk.SetHost before using the sdk outside the wasm VM")
+}
+
+func (unsetHost) Log(msg string) {
+	panic("sdk: no Host set, call sdk.SetHost before using the sdk outside the wasm VM")
+}
+
+func (unsetHost) LogWithType(msg string, msgType int32) {
+	panic("sdk: no Host set, call sdk.SetHost before using the sdk outside the wasm VM")
+}
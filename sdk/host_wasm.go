@@ -0,0 +1,72 @@
+//go:build wasm
+// +build wasm
+
+/*
+Copyright (C) BABEC. All rights reserved.
+Copyright (C) THL A29 Limited, a Tencent company. All rights reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package sdk
+
+import "unsafe"
+
+var host Host = wasmHost{}
+
+// wasmHost implement Host by the imports of the chainmaker wasm VM
+type wasmHost struct{}
+
+func (wasmHost) SysCall(requestHeader string, requestBody string) int32 {
+	return wasmSysCall(requestHeader, requestBody)
+}
+
+func (wasmHost) Log(msg string) {
+	wasmLogMessage(msg)
+}
+
+func (wasmHost) LogWithType(msg string, msgType int32) {
+	wasmLogMessageWithType(msg, msgType)
+}
+
+// wasmSysCall provides data interaction with the chain. sysCallReq common param, request var param
+//
+//go:wasmimport env sys_call
+func wasmSysCall(requestHeader string, requestBody string) int32
+
+//go:wasmimport env log_message
+func wasmLogMessage(msg string)
+
+//go:wasmimport env log_message_with_type
+func wasmLogMessageWithType(msg string, msgType int32)
+
+// sysCall provides data interaction with the chain. sysCallReq common param, request var param
+func sysCall(requestHeader string, requestBody string) int32 {
+	return host.SysCall(requestHeader, requestBody)
+}
+
+// ptrOf return the linear memory address of b, b must not be empty
+func ptrOf(b []byte) int32 {
+	return int32(uintptr(unsafe.Pointer(&b[0])))
+}
+
+//go:wasmexport runtime_type
+func runtimeType() int32 {
+	var ContractRuntimeGoSdkType int32 = 4
+	resetArgs()
+	return ContractRuntimeGoSdkType
+}
+
+//go:wasmexport deallocate
+func deallocate(size int32) {
+	argsBytes = make([]byte, size)
+	resetArgs()
+}
+
+//go:wasmexport allocate
+func allocate(size int32) uintptr {
+	argsBytes = make([]byte, size)
+	resetArgs()
+
+	return uintptr(unsafe.Pointer(&argsBytes[0]))
+}
@@ -0,0 +1,97 @@
+/*
+Copyright (C) BABEC. All rights reserved.
+Copyright (C) THL A29 Limited, a Tencent company. All rights reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package sdk
+
+import "fmt"
+
+// KV one row of a ResultSetKV
+type KV struct {
+	Key   string
+	Field string
+	Value []byte
+}
+
+// ForEachKV call fn for every row of rs until fn returns false. rs is closed whatever happens.
+// A failing row or Close of an iterator of the sdk is returned as the *ErrHostCall of the failing
+// sys_call, of another ResultSetKV as an error wrapping ErrIterator:
+//
+//	rs, code := ctx.NewIteratorPrefixWithKey("balance")
+//	if code != sdk.SUCCESS {
+//		return sdk.Error(sdk.StatusError, "iterate balance failed")
+//	}
+//	err := sdk.ForEachKV(rs, func(key, field string, value []byte) bool {
+//		total += parse(value)
+//		return true
+//	})
+func ForEachKV(rs ResultSetKV, fn func(key string, field string, value []byte) bool) (err error) {
+	defer closeResultSet(rs, &err)
+	for rs.HasNext() {
+		key, field, value, code := rs.Next()
+		if code != SUCCESS {
+			return iteratorError(rs, "Next", code)
+		}
+		if !fn(key, field, value) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// ForEachRow call fn for every row of the sql rs until fn returns false, rs is closed whatever happens.
+// The errors are the ones of ForEachKV
+func ForEachRow(rs ResultSet, fn func(row *EasyCodec) bool) (err error) {
+	defer closeResultSet(rs, &err)
+	for rs.HasNext() {
+		row, code := rs.NextRow()
+		if code != SUCCESS {
+			return iteratorError(rs, "NextRow", code)
+		}
+		if !fn(row) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// ForEachHistory call fn for every modification of it until fn returns false, it is closed whatever happens.
+// The errors are the ones of ForEachKV
+func ForEachHistory(it KeyHistoryKvIter, fn func(m *KeyModification) bool) (err error) {
+	defer closeResultSet(it, &err)
+	for it.HasNext() {
+		m, code := it.Next()
+		if code != SUCCESS {
+			return iteratorError(it, "Next", code)
+		}
+		if !fn(m) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// closeResultSet close rs, its failure is reported in err unless err is already set
+func closeResultSet(rs ResultSet, err *error) {
+	if _, code := rs.Close(); code != SUCCESS && *err == nil {
+		*err = iteratorError(rs, "Close", code)
+	}
+}
+
+// hostErrorer the iterators of the sdk, keeping the *ErrHostCall of their last failing sys_call
+type hostErrorer interface {
+	hostError() error
+}
+
+// iteratorError the error of method of rs returning code
+func iteratorError(rs ResultSet, method string, code ResultCode) error {
+	if h, ok := rs.(hostErrorer); ok {
+		if err := h.hostError(); err != nil {
+			return err
+		}
+	}
+	return fmt.Errorf("%T.%s returned %d: %w", rs, method, code, ErrIterator)
+}
@@ -0,0 +1,150 @@
+/*
+Copyright (C) BABEC. All rights reserved.
+Copyright (C) THL A29 Limited, a Tencent company. All rights reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package sdk
+
+import (
+	"fmt"
+	"math"
+)
+
+// IteratorOptions order and bounds of a range iterator, the zero value is the plain ascending
+// iterator of NewIteratorWithField
+type IteratorOptions struct {
+	// Descending iterate from the last row of the range to the first
+	Descending bool
+	// Skip the first rows, in the order of iteration
+	Skip int
+	// MaxRows return at most so many rows after Skip, 0 for no bound
+	MaxRows int
+	// KeysOnly return rows without their value, which is then empty
+	KeysOnly bool
+}
+
+// NewIteratorWithOptionsE range of [startKey+"#"+startField, limitKey+"#"+limitField), front closed
+// back open, iterated as opts asks. The latest 10 rows of "order#" are:
+//
+//	rs, err := sdk.NewIteratorWithOptionsE("order", "", "order$", "",
+//		sdk.IteratorOptions{Descending: true, MaxRows: 10})
+//
+// When opts is not zero the KvIterator request carries descending, skip, max_rows and keys_only
+// as int32, and options_ack_ptr. A host honouring them writes 1 as le int32 through
+// options_ack_ptr. A host that does not know them leaves -1 and iterates the whole range in
+// ascending order with values: the sdk then skips, bounds and drops values itself, but cannot
+// reverse the range, Descending is ErrUnsupported. A negative Skip or MaxRows is ErrInvalidArgument
+func NewIteratorWithOptionsE(startKey string, startField string, limitKey string, limitField string,
+	opts IteratorOptions) (ResultSetKV, error) {
+	if opts.Skip < 0 || opts.Skip > math.MaxInt32 || opts.MaxRows < 0 || opts.MaxRows > math.MaxInt32 {
+		return nil, fmt.Errorf("iterator skip %d, max rows %d out of [0, %d]: %w", opts.Skip, opts.MaxRows,
+			math.MaxInt32, ErrInvalidArgument)
+	}
+	var ack int32 = -1
+	ec := NewEasyCodec()
+	ec.AddString("start_key", startKey)
+	ec.AddString("start_field", startField)
+	ec.AddString("limit_key", limitKey)
+	ec.AddString("limit_field", limitField)
+	if opts != (IteratorOptions{}) {
+		ec.AddInt32("descending", boolToInt32(opts.Descending))
+		ec.AddInt32("skip", int32(opts.Skip))
+		ec.AddInt32("max_rows", int32(opts.MaxRows))
+		ec.AddInt32("keys_only", boolToInt32(opts.KeysOnly))
+		ec.AddInt32("options_ack_ptr", int32Ptr(&ack))
+	}
+	index, err := GetInt32FromChainE(ec, ContractMethodKvIterator)
+	if err != nil {
+		return nil, err
+	}
+	rs := &ResultSetKvImpl{index: index}
+	if opts == (IteratorOptions{}) || ack == 1 {
+		return rs, nil
+	}
+	if opts.Descending {
+		rs.Close()
+		return nil, fmt.Errorf("descending iteration: %w", ErrUnsupported)
+	}
+	return &boundedResultSet{
+		rs:        rs,
+		skip:      opts.Skip,
+		bounded:   opts.MaxRows > 0,
+		remaining: opts.MaxRows,
+		keysOnly:  opts.KeysOnly,
+	}, nil
+}
+
+func boolToInt32(b bool) int32 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// boundedResultSet emulate Skip, MaxRows and KeysOnly over a host ignoring them
+type boundedResultSet struct {
+	rs        ResultSetKV
+	skip      int
+	bounded   bool
+	remaining int
+	keysOnly  bool
+	// failed a skipped row could not be read, reported by the next NextRow
+	failed bool
+}
+
+// skipRows read and drop the rows still to skip
+func (b *boundedResultSet) skipRows() {
+	for b.skip > 0 && !b.failed && b.rs.HasNext() {
+		b.skip--
+		_, code := b.rs.NextRow()
+		b.failed = code != SUCCESS
+	}
+}
+
+func (b *boundedResultSet) HasNext() bool {
+	b.skipRows()
+	if b.failed {
+		return true
+	}
+	if b.bounded && b.remaining == 0 {
+		return false
+	}
+	return b.rs.HasNext()
+}
+
+func (b *boundedResultSet) NextRow() (*EasyCodec, ResultCode) {
+	b.skipRows()
+	if b.failed || (b.bounded && b.remaining == 0) {
+		return nil, ERROR
+	}
+	row, code := b.rs.NextRow()
+	if code != SUCCESS {
+		return nil, ERROR
+	}
+	b.remaining--
+	if b.keysOnly {
+		row.RemoveKey("value")
+	}
+	return row, SUCCESS
+}
+
+func (b *boundedResultSet) Next() (string, string, []byte, ResultCode) {
+	row, code := b.NextRow()
+	if code != SUCCESS {
+		return "", "", nil, ERROR
+	}
+	return kvOfRow(row)
+}
+
+func (b *boundedResultSet) Close() (bool, ResultCode) {
+	return b.rs.Close()
+}
+
+func (b *boundedResultSet) hostError() error {
+	if h, ok := b.rs.(hostErrorer); ok {
+		return h.hostError()
+	}
+	return nil
+}
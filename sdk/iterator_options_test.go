@@ -0,0 +1,99 @@
+//go:build !wasm
+// +build !wasm
+
+/*
+Copyright (C) BABEC. All rights reserved.
+Copyright (C) THL A29 Limited, a Tencent company. All rights reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package sdk_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/TKOTKCh/contract-sdk-go-wasm/sdk"
+	"github.com/TKOTKCh/contract-sdk-go-wasm/sdk/mock"
+)
+
+// orders chain holding order#1 to order#5, honouring the iterator options or not
+func orders(supported bool) *mock.Chain {
+	chain := mock.NewChain()
+	chain.SetIteratorOptions(supported)
+	for _, field := range []string{"1", "2", "3", "4", "5"} {
+		chain.SetState("order", field, []byte("v"+field))
+	}
+	return chain
+}
+
+// fields return the field=value rows of an iterator over the orders with opts
+func fields(t *testing.T, ctx sdk.SimContext, opts sdk.IteratorOptions) string {
+	t.Helper()
+	rs, code := ctx.(sdk.IteratorOptionsContext).NewIteratorWithOptions("order", "", "order", "9", opts)
+	if code != sdk.SUCCESS {
+		t.Fatalf("iterator with %+v failed", opts)
+	}
+	var got []string
+	if err := sdk.ForEachKV(rs, func(key string, field string, value []byte) bool {
+		got = append(got, field+"="+string(value))
+		return true
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return strings.Join(got, ",")
+}
+
+func TestIteratorOptionsEmulated(t *testing.T) {
+	tests := []struct {
+		opts sdk.IteratorOptions
+		want string
+	}{
+		{sdk.IteratorOptions{}, "1=v1,2=v2,3=v3,4=v4,5=v5"},
+		{sdk.IteratorOptions{Skip: 1, MaxRows: 2}, "2=v2,3=v3"},
+		{sdk.IteratorOptions{Skip: 4, MaxRows: 3}, "5=v5"},
+		{sdk.IteratorOptions{Skip: 9}, ""},
+		{sdk.IteratorOptions{MaxRows: 2, KeysOnly: true}, "1=,2="},
+	}
+	for _, supported := range []bool{true, false} {
+		orders(supported).Invoke(func() {
+			for _, ctx := range []sdk.SimContext{sdk.NewSimContext(), sdk.NewCachedSimContext()} {
+				for _, tt := range tests {
+					if got := fields(t, ctx, tt.opts); got != tt.want {
+						t.Errorf("supported %v, %T, %+v: got %s, want %s", supported, ctx, tt.opts, got, tt.want)
+					}
+				}
+			}
+		}, nil)
+	}
+}
+
+func TestIteratorOptionsDescending(t *testing.T) {
+	orders(true).Invoke(func() {
+		if got := fields(t, sdk.NewSimContext(), sdk.IteratorOptions{Descending: true, MaxRows: 2}); got != "5=v5,4=v4" {
+			t.Errorf("descending got %s", got)
+		}
+	}, nil)
+	orders(false).Invoke(func() {
+		_, err := sdk.NewIteratorWithOptionsE("order", "", "order", "9", sdk.IteratorOptions{Descending: true})
+		if !errors.Is(err, sdk.ErrUnsupported) {
+			t.Errorf("descending without host support: got %v, want ErrUnsupported", err)
+		}
+		ctx := sdk.NewSimContext().(sdk.IteratorOptionsContext)
+		if _, code := ctx.NewIteratorWithOptions("order", "", "order", "9", sdk.IteratorOptions{Descending: true}); code != sdk.ERROR {
+			t.Errorf("descending without host support returned %d", code)
+		}
+	}, nil)
+}
+
+func TestIteratorOptionsInvalid(t *testing.T) {
+	orders(true).Invoke(func() {
+		for _, opts := range []sdk.IteratorOptions{{Skip: -1}, {MaxRows: -1}} {
+			if _, err := sdk.NewIteratorWithOptionsE("order", "", "order", "9", opts); !errors.Is(err, sdk.ErrInvalidArgument) {
+				t.Errorf("%+v: got %v, want ErrInvalidArgument", opts, err)
+			}
+		}
+	}, nil)
+}
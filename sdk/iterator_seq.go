@@ -0,0 +1,65 @@
+//go:build go1.23
+// +build go1.23
+
+/*
+Copyright (C) BABEC. All rights reserved.
+Copyright (C) THL A29 Limited, a Tencent company. All rights reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package sdk
+
+import "iter"
+
+// AllKV range over the rows of rs, which is closed when the loop ends, by break or error.
+// An error is yielded once with a zero KV and ends the loop:
+//
+//	for kv, err := range sdk.AllKV(rs) {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+//
+// rs is only closed by ranging over the sequence, once
+func AllKV(rs ResultSetKV) iter.Seq2[KV, error] {
+	return func(yield func(KV, error) bool) {
+		stopped := false
+		err := ForEachKV(rs, func(key string, field string, value []byte) bool {
+			stopped = !yield(KV{Key: key, Field: field, Value: value}, nil)
+			return !stopped
+		})
+		if err != nil && !stopped {
+			yield(KV{}, err)
+		}
+	}
+}
+
+// AllRows range over the rows of the sql rs, which is closed when the loop ends, as AllKV
+func AllRows(rs ResultSet) iter.Seq2[*EasyCodec, error] {
+	return func(yield func(*EasyCodec, error) bool) {
+		stopped := false
+		err := ForEachRow(rs, func(row *EasyCodec) bool {
+			stopped = !yield(row, nil)
+			return !stopped
+		})
+		if err != nil && !stopped {
+			yield(nil, err)
+		}
+	}
+}
+
+// AllHistory range over the modifications of it, which is closed when the loop ends, as AllKV
+func AllHistory(it KeyHistoryKvIter) iter.Seq2[*KeyModification, error] {
+	return func(yield func(*KeyModification, error) bool) {
+		stopped := false
+		err := ForEachHistory(it, func(m *KeyModification) bool {
+			stopped = !yield(m, nil)
+			return !stopped
+		})
+		if err != nil && !stopped {
+			yield(nil, err)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+//go:build go1.23
+// +build go1.23
+
+/*
+Copyright (C) BABEC. All rights reserved.
+Copyright (C) THL A29 Limited, a Tencent company. All rights reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package sdk
+
+import "testing"
+
+func TestAllClosesOnBreak(t *testing.T) {
+	rows := newFakeRows(5, -1)
+	var got []string
+	for kv, err := range AllKV(fakeKVRows{rows}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, kv.Field)
+		if kv.Field == "1" {
+			break
+		}
+	}
+	if len(got) != 2 || rows.closed != 1 {
+		t.Fatalf("got %v, closed %d times", got, rows.closed)
+	}
+
+	rows = newFakeRows(5, -1)
+	for range AllRows(rows) {
+		break
+	}
+	if rows.closed != 1 {
+		t.Fatalf("sql closed %d times", rows.closed)
+	}
+}
+
+func TestAllClosesOnError(t *testing.T) {
+	rows := newFakeRows(5, 2)
+	var errs, n int
+	for kv, err := range AllKV(fakeKVRows{rows}) {
+		if err != nil {
+			if kv.Key != "" || kv.Value != nil || !failedIn(err, "Next") {
+				t.Fatalf("error yielded with %+v, %v", kv, err)
+			}
+			errs++
+			continue
+		}
+		n++
+	}
+	if n != 2 || errs != 1 || rows.closed != 1 {
+		t.Fatalf("%d rows, %d errors, closed %d times", n, errs, rows.closed)
+	}
+
+	rows = newFakeRows(3, 1)
+	errs = 0
+	for m, err := range AllHistory(fakeHistory{rows}) {
+		if err != nil {
+			if m != nil || !failedIn(err, "Next") {
+				t.Fatalf("error yielded with %+v, %v", m, err)
+			}
+			errs++
+		}
+	}
+	if errs != 1 || rows.closed != 1 {
+		t.Fatalf("history %d errors, closed %d times", errs, rows.closed)
+	}
+
+	// breaking at the error does not yield again
+	rows = newFakeRows(3, -1)
+	rows.closeCode = ERROR
+	errs = 0
+	for _, err := range AllRows(rows) {
+		if err != nil {
+			errs++
+			break
+		}
+	}
+	if errs != 1 || rows.closed != 1 {
+		t.Fatalf("close failure yielded %d times, closed %d times", errs, rows.closed)
+	}
+}
@@ -0,0 +1,164 @@
+/*
+Copyright (C) BABEC. All rights reserved.
+Copyright (C) THL A29 Limited, a Tencent company. All rights reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package sdk
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// fakeRows n rows numbered from 0, the row failAt fails and Close returns closeCode
+type fakeRows struct {
+	n, pos    int
+	failAt    int
+	closeCode ResultCode
+	closed    int
+}
+
+func newFakeRows(n int, failAt int) *fakeRows {
+	return &fakeRows{n: n, failAt: failAt}
+}
+
+func (r *fakeRows) step() (int, ResultCode) {
+	r.pos++
+	if r.pos-1 == r.failAt {
+		return 0, ERROR
+	}
+	return r.pos - 1, SUCCESS
+}
+
+func (r *fakeRows) HasNext() bool {
+	return r.pos < r.n
+}
+
+func (r *fakeRows) NextRow() (*EasyCodec, ResultCode) {
+	i, code := r.step()
+	ec := NewEasyCodec()
+	ec.AddInt32("i", int32(i))
+	return ec, code
+}
+
+func (r *fakeRows) Close() (bool, ResultCode) {
+	r.closed++
+	return r.closeCode == SUCCESS, r.closeCode
+}
+
+// fakeKVRows fakeRows as a ResultSetKV
+type fakeKVRows struct {
+	*fakeRows
+}
+
+func (r fakeKVRows) Next() (string, string, []byte, ResultCode) {
+	i, code := r.step()
+	return "k", strconv.Itoa(i), nil, code
+}
+
+// fakeHistory fakeRows as a KeyHistoryKvIter
+type fakeHistory struct {
+	*fakeRows
+}
+
+func (r fakeHistory) Next() (*KeyModification, ResultCode) {
+	i, code := r.step()
+	return &KeyModification{BlockHeight: i}, code
+}
+
+// failedIn whether err is the ErrIterator of method of a fake iterator
+func failedIn(err error, method string) bool {
+	return errors.Is(err, ErrIterator) && strings.Contains(err.Error(), "."+method+" returned")
+}
+
+func TestForEachClosesOnStop(t *testing.T) {
+	rows := newFakeRows(5, -1)
+	var got []string
+	err := ForEachKV(fakeKVRows{rows}, func(key string, field string, value []byte) bool {
+		got = append(got, field)
+		return len(got) < 2
+	})
+	if err != nil || len(got) != 2 || rows.closed != 1 {
+		t.Fatalf("got %v, %v, closed %d times", got, err, rows.closed)
+	}
+
+	rows = newFakeRows(3, -1)
+	n := 0
+	err = ForEachHistory(fakeHistory{rows}, func(m *KeyModification) bool {
+		n++
+		return true
+	})
+	if err != nil || n != 3 || rows.closed != 1 {
+		t.Fatalf("history %d rows, %v, closed %d times", n, err, rows.closed)
+	}
+}
+
+func TestForEachClosesOnError(t *testing.T) {
+	rows := newFakeRows(5, 2)
+	n := 0
+	err := ForEachKV(fakeKVRows{rows}, func(key string, field string, value []byte) bool {
+		n++
+		return true
+	})
+	if !failedIn(err, "Next") || n != 2 || rows.closed != 1 {
+		t.Fatalf("kv %d rows, %v, closed %d times", n, err, rows.closed)
+	}
+
+	rows = newFakeRows(5, 0)
+	err = ForEachRow(rows, func(row *EasyCodec) bool {
+		t.Fatal("the failing row was passed")
+		return true
+	})
+	if !failedIn(err, "NextRow") || rows.closed != 1 {
+		t.Fatalf("sql %v, closed %d times", err, rows.closed)
+	}
+
+	rows = newFakeRows(5, 1)
+	err = ForEachHistory(fakeHistory{rows}, func(m *KeyModification) bool { return true })
+	if !failedIn(err, "Next") || rows.closed != 1 {
+		t.Fatalf("history %v, closed %d times", err, rows.closed)
+	}
+}
+
+func TestForEachReportsClose(t *testing.T) {
+	rows := newFakeRows(2, -1)
+	rows.closeCode = ERROR
+	err := ForEachKV(fakeKVRows{rows}, func(key string, field string, value []byte) bool { return true })
+	if !failedIn(err, "Close") {
+		t.Fatalf("close failure %v", err)
+	}
+
+	// the row error is kept over the close failure
+	rows = newFakeRows(2, 1)
+	rows.closeCode = ERROR
+	err = ForEachKV(fakeKVRows{rows}, func(key string, field string, value []byte) bool { return true })
+	if !failedIn(err, "Next") || rows.closed != 1 {
+		t.Fatalf("row and close failure %v", err)
+	}
+}
+
+func TestForEachReturnsHostError(t *testing.T) {
+	h := &fakeHost{value: []byte("x"), fail: map[string]int32{ContractMethodKvIteratorNextLen: 7}}
+	withFakeHost(t, h)
+
+	rs, code := NewSimContext().NewIteratorPrefixWithKey("balance")
+	if code != SUCCESS {
+		t.Fatalf("new iterator %d", code)
+	}
+	err := ForEachKV(rs, func(key string, field string, value []byte) bool { return true })
+	var hostErr *ErrHostCall
+	if !errors.As(err, &hostErr) || hostErr.Method != ContractMethodKvIteratorNextLen || hostErr.Code != 7 {
+		t.Fatalf("got %v, want the failing sys_call", err)
+	}
+
+	h.fail = map[string]int32{ContractMethodKvIteratorClose: 5}
+	rs, _ = NewSimContext().NewIteratorPrefixWithKey("balance")
+	err = ForEachKV(rs, func(key string, field string, value []byte) bool { return false })
+	if !errors.As(err, &hostErr) || hostErr.Method != ContractMethodKvIteratorClose || hostErr.Code != 5 {
+		t.Fatalf("close: got %v, want the failing sys_call", err)
+	}
+}
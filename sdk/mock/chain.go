@@ -0,0 +1,312 @@
+//go:build !wasm
+// +build !wasm
+
+/*
+Copyright (C) BABEC. All rights reserved.
+Copyright (C) THL A29 Limited, a Tencent company. All rights reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package mock provides an in-memory chain implementing the sys_call protocol of the sdk,
+// so that contracts can be tested natively with go test:
+//
+//	chain := mock.NewChain()
+//	chain.SetState("balance", "alice", []byte("100"))
+//	chain.SetSender(mock.Identity{OrgId: "org1", Address: "alice"})
+//	result := chain.Invoke(transfer, map[string][]byte{"to": []byte("bob")})
+//	// assert on result.WriteSet, result.Events, result.Payload ...
+package mock
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/TKOTKCh/contract-sdk-go-wasm/sdk"
+)
+
+// Identity creator or sender of a transaction
+type Identity struct {
+	OrgId   string
+	Role    string
+	Pk      string
+	Address string
+}
+
+// ContractFunc handle a cross contract call to a registered contract
+type ContractFunc func(method string, args map[string][]byte) ([]byte, error)
+
+// Write one entry of the write set of a transaction
+type Write struct {
+	Key      string
+	Field    string
+	Value    []byte
+	IsDelete bool
+}
+
+// Event emitted by a transaction
+type Event struct {
+	Topic string
+	Data  []string
+}
+
+// Result of a transaction executed by Chain.Invoke
+type Result struct {
+	TxId string
+	// IsError is true once the contract called ErrorResult
+	IsError bool
+	// Payload last msg of SuccessResult
+	Payload []byte
+	// Message msg of ErrorResult, multiple calls are appended
+	Message  string
+	WriteSet []*Write
+	Events   []*Event
+	Logs     []string
+}
+
+type entry struct {
+	key   string
+	field string
+	value []byte
+}
+
+// Chain in-memory chain, implement sdk.Host
+type Chain struct {
+	state     map[string]*entry
+	history   map[string][]*sdk.KeyModification
+	contracts map[string]ContractFunc
+
+	creator     Identity
+	sender      Identity
+	txId        string
+	txCount     int
+	blockHeight int
+	timestamp   int64
+
+	// ignoreIteratorOptions behave as a host not knowing the options of KvIterator
+	ignoreIteratorOptions bool
+
+	tx *txContext
+}
+
+// NewChain create an empty chain at block height 1
+func NewChain() *Chain {
+	return &Chain{
+		state:       make(map[string]*entry),
+		history:     make(map[string][]*sdk.KeyModification),
+		contracts:   make(map[string]ContractFunc),
+		blockHeight: 1,
+	}
+}
+
+// SetState seed committed state, value is copied
+func (c *Chain) SetState(key string, field string, value []byte) {
+	c.state[compositeKey(key, field)] = &entry{key: key, field: field, value: append([]byte{}, value...)}
+}
+
+// GetState return a copy of committed state
+func (c *Chain) GetState(key string, field string) ([]byte, bool) {
+	e, ok := c.state[compositeKey(key, field)]
+	if !ok {
+		return nil, false
+	}
+	return append([]byte{}, e.value...), true
+}
+
+// SetIteratorOptions set whether the chain honours the sdk.IteratorOptions of a range iterator,
+// false to test a contract against a host that does not, where the sdk emulates them
+func (c *Chain) SetIteratorOptions(supported bool) {
+	c.ignoreIteratorOptions = !supported
+}
+
+// SetCreator set the creator of the next transactions
+func (c *Chain) SetCreator(creator Identity) {
+	c.creator = creator
+}
+
+// SetSender set the sender of the next transactions
+func (c *Chain) SetSender(sender Identity) {
+	c.sender = sender
+}
+
+// SetTxId set the tx id of the next transaction, by default tx ids are generated
+func (c *Chain) SetTxId(txId string) {
+	c.txId = txId
+}
+
+// SetBlockHeight set the block height of the next transactions
+func (c *Chain) SetBlockHeight(blockHeight int) {
+	c.blockHeight = blockHeight
+}
+
+// SetTxTimeStamp set the tx timestamp of the next transactions
+func (c *Chain) SetTxTimeStamp(timestamp int64) {
+	c.timestamp = timestamp
+}
+
+// RegisterContract register a contract that can be reached by CallContract
+func (c *Chain) RegisterContract(name string, fn ContractFunc) {
+	c.contracts[name] = fn
+}
+
+// Invoke execute method as one transaction with args. The write set is committed unless the
+// contract reported an error. The chain is the sdk Host during method, the previous Host is restored after
+func (c *Chain) Invoke(method func(), args map[string][]byte) *Result {
+	c.txCount++
+	txId := c.txId
+	if txId == "" {
+		txId = "mock_tx_" + strconv.Itoa(c.txCount)
+	}
+	c.txId = ""
+	c.tx = newTxContext(txId)
+	defer func() { c.tx = nil }()
+
+	defer sdk.SetHost(sdk.SetHost(c))
+	sdk.SetArgs(c.txArgs(txId, args).Marshal())
+	method()
+
+	result := c.tx.result
+	result.WriteSet = c.tx.writeSet()
+	if !result.IsError {
+		c.commit(result.WriteSet)
+	}
+	return result
+}
+
+// InvokeContract call method of contract as one transaction, through the same entry as the VM
+func (c *Chain) InvokeContract(contract *sdk.Contract, method string, args map[string][]byte) *Result {
+	params := make(map[string][]byte, len(args)+1)
+	for k, v := range args {
+		params[k] = v
+	}
+	params[sdk.ContractParamMethod] = []byte(method)
+	return c.Invoke(func() {
+		sdk.Serve(contract)
+		if method == sdk.MethodInitContract || method == sdk.MethodUpgrade {
+			sdk.Dispatch(method)
+			return
+		}
+		sdk.InvokeContract()
+	}, params)
+}
+
+func (c *Chain) txArgs(txId string, args map[string][]byte) *sdk.EasyCodec {
+	params := make(map[string][]byte, len(args)+10)
+	for k, v := range args {
+		params[k] = v
+	}
+	params[sdk.ContractParamCreatorOrgId] = []byte(c.creator.OrgId)
+	params[sdk.ContractParamCreatorRole] = []byte(c.creator.Role)
+	params[sdk.ContractParamCreatorPk] = []byte(c.creator.Pk)
+	params[sdk.ContractParamSenderOrgId] = []byte(c.sender.OrgId)
+	params[sdk.ContractParamSenderRole] = []byte(c.sender.Role)
+	params[sdk.ContractParamSenderPk] = []byte(c.sender.Pk)
+	params[sdk.ContractParamBlockHeight] = []byte(strconv.Itoa(c.blockHeight))
+	params[sdk.ContractParamTxId] = []byte(txId)
+	params[sdk.ContractParamContextPtr] = []byte("1")
+	params[sdk.ContractParamTxTimeStamp] = []byte(strconv.FormatInt(c.timestamp, 10))
+	return sdk.NewEasyCodecWithMap(params)
+}
+
+func (c *Chain) commit(writes []*Write) {
+	for _, w := range writes {
+		k := compositeKey(w.Key, w.Field)
+		if w.IsDelete {
+			delete(c.state, k)
+		} else {
+			c.state[k] = &entry{key: w.Key, field: w.Field, value: w.Value}
+		}
+		c.history[k] = append(c.history[k], &sdk.KeyModification{
+			Key:         w.Key,
+			Field:       w.Field,
+			Value:       w.Value,
+			TxId:        c.tx.txId,
+			BlockHeight: c.blockHeight,
+			IsDelete:    w.IsDelete,
+			Timestamp:   strconv.FormatInt(c.timestamp, 10),
+		})
+	}
+}
+
+// txContext state of the transaction being executed
+type txContext struct {
+	txId       string
+	writes     map[string]*Write
+	writeOrder []string
+	iterators  map[int32]*iterator
+	iterIndex  int32
+	pending    []byte
+	result     *Result
+}
+
+func newTxContext(txId string) *txContext {
+	return &txContext{
+		txId:      txId,
+		writes:    make(map[string]*Write),
+		iterators: make(map[int32]*iterator),
+		result:    &Result{TxId: txId},
+	}
+}
+
+func (t *txContext) put(w *Write) {
+	k := compositeKey(w.Key, w.Field)
+	if _, ok := t.writes[k]; !ok {
+		t.writeOrder = append(t.writeOrder, k)
+	}
+	t.writes[k] = w
+}
+
+func (t *txContext) writeSet() []*Write {
+	writes := make([]*Write, 0, len(t.writeOrder))
+	for _, k := range t.writeOrder {
+		writes = append(writes, t.writes[k])
+	}
+	return writes
+}
+
+// get read [key, field] with the writes of the current transaction applied
+func (c *Chain) get(key string, field string) ([]byte, bool) {
+	k := compositeKey(key, field)
+	if w, ok := c.tx.writes[k]; ok {
+		if w.IsDelete {
+			return nil, false
+		}
+		return w.Value, true
+	}
+	return c.GetState(key, field)
+}
+
+// snapshot return the visible entries of the current transaction matching fn, ordered by composite key
+func (c *Chain) snapshot(fn func(compositeKey string) bool) []*entry {
+	merged := make(map[string]*entry)
+	for k, e := range c.state {
+		merged[k] = e
+	}
+	for k, w := range c.tx.writes {
+		if w.IsDelete {
+			delete(merged, k)
+		} else {
+			merged[k] = &entry{key: w.Key, field: w.Field, value: w.Value}
+		}
+	}
+	keys := make([]string, 0, len(merged))
+	for k := range merged {
+		if fn(k) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	entries := make([]*entry, 0, len(keys))
+	for _, k := range keys {
+		entries = append(entries, merged[k])
+	}
+	return entries
+}
+
+// compositeKey the chain stores [key, field] as key+"#"+field, or key when field is empty
+func compositeKey(key string, field string) string {
+	if field == "" {
+		return key
+	}
+	return key + "#" + field
+}
@@ -0,0 +1,218 @@
+//go:build !wasm
+// +build !wasm
+
+/*
+Copyright (C) BABEC. All rights reserved.
+Copyright (C) THL A29 Limited, a Tencent company. All rights reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package mock
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/TKOTKCh/contract-sdk-go-wasm/sdk"
+)
+
+// rows return the [key#field=value] rows of rs
+func rows(t *testing.T, rs sdk.ResultSetKV, code sdk.ResultCode) []string {
+	t.Helper()
+	if code != sdk.SUCCESS {
+		t.Fatalf("new iterator %d", code)
+	}
+	var got []string
+	err := sdk.ForEachKV(rs, func(key string, field string, value []byte) bool {
+		got = append(got, compositeKey(key, field)+"="+string(value))
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+func TestSeedAndInvoke(t *testing.T) {
+	c := NewChain()
+	c.SetState("balance", "alice", []byte("100"))
+	c.SetSender(Identity{OrgId: "org1", Address: "alice"})
+
+	result := c.Invoke(func() {
+		ctx := sdk.NewSimContext()
+		if value, _ := ctx.GetState("balance", "alice"); value != "100" {
+			t.Errorf("seeded state %q", value)
+		}
+		if to, _ := ctx.ArgString("to"); to != "bob" {
+			t.Errorf("arg to %q", to)
+		}
+		if org, _ := ctx.GetSenderOrgId(); org != "org1" {
+			t.Errorf("sender org %q", org)
+		}
+		ctx.PutState("balance", "bob", "1")
+		ctx.PutState("balance", "alice", "99")
+		ctx.DeleteState("balance", "carol")
+		if value, _ := ctx.GetState("balance", "bob"); value != "1" {
+			t.Errorf("the transaction does not read its own write, got %q", value)
+		}
+		ctx.EmitEvent("transfer", "alice", "bob")
+		ctx.SuccessResult("ok")
+	}, map[string][]byte{"to": []byte("bob")})
+
+	if result.IsError || string(result.Payload) != "ok" || result.TxId != "mock_tx_1" {
+		t.Fatalf("result %+v", result)
+	}
+	want := []string{"balance#bob=1", "balance#alice=99", "balance#carol deleted"}
+	if len(result.WriteSet) != len(want) {
+		t.Fatalf("write set %+v", result.WriteSet)
+	}
+	for i, w := range result.WriteSet {
+		got := compositeKey(w.Key, w.Field) + "=" + string(w.Value)
+		if w.IsDelete {
+			got = compositeKey(w.Key, w.Field) + " deleted"
+		}
+		if got != want[i] {
+			t.Fatalf("write %d %s, want %s", i, got, want[i])
+		}
+	}
+	if len(result.Events) != 1 || result.Events[0].Topic != "transfer" || strings.Join(result.Events[0].Data, ",") != "alice,bob" {
+		t.Fatalf("events %+v", result.Events)
+	}
+	if value, _ := c.GetState("balance", "alice"); string(value) != "99" {
+		t.Fatalf("committed %q", value)
+	}
+}
+
+// nopHost Host that answers every sys_call with an error
+type nopHost struct{}
+
+func (nopHost) SysCall(requestHeader string, requestBody string) int32 { return 1 }
+func (nopHost) Log(msg string)                                         {}
+func (nopHost) LogWithType(msg string, msgType int32)                  {}
+
+func TestInvokeRestoresHost(t *testing.T) {
+	sdk.SetHost(nopHost{})
+	NewChain().Invoke(func() {}, nil)
+	if previous := sdk.SetHost(nil); previous != (nopHost{}) {
+		t.Fatalf("host after Invoke %T", previous)
+	}
+}
+
+func TestStateIsCopied(t *testing.T) {
+	c := NewChain()
+	value := []byte("100")
+	c.SetState("balance", "alice", value)
+	value[0] = '9'
+	got, _ := c.GetState("balance", "alice")
+	got[1] = '9'
+	if again, _ := c.GetState("balance", "alice"); string(again) != "100" {
+		t.Fatalf("state changed through the caller slices to %s", again)
+	}
+}
+
+func TestFailedInvokeNotCommitted(t *testing.T) {
+	c := NewChain()
+	result := c.Invoke(func() {
+		ctx := sdk.NewSimContext()
+		ctx.PutState("balance", "alice", "1")
+		ctx.ErrorResult("bad")
+		ctx.SuccessResult("ignored")
+	}, nil)
+	if !result.IsError || result.Message != "bad" || result.Payload != nil {
+		t.Fatalf("result %+v", result)
+	}
+	if _, ok := c.GetState("balance", "alice"); ok {
+		t.Fatal("the write set of a failed transaction was committed")
+	}
+}
+
+func TestKvIteratorBounds(t *testing.T) {
+	c := NewChain()
+	for _, field := range []string{"1", "2", "3"} {
+		c.SetState("a", field, []byte("v"+field))
+	}
+	c.SetState("a", "", []byte("v"))
+	c.SetState("b", "", []byte("v"))
+	c.SetState("ab", "1", []byte("v"))
+
+	joined := func(rs sdk.ResultSetKV, code sdk.ResultCode) string {
+		return strings.Join(rows(t, rs, code), ",")
+	}
+	c.Invoke(func() {
+		ctx := sdk.NewSimContext()
+		got := joined(ctx.NewIteratorWithField("a", "1", "3"))
+		if got != "a#1=v1,a#2=v2" {
+			t.Errorf("[a#1, a#3) got %s", got)
+		}
+		got = joined(ctx.NewIterator("a", "b"))
+		if got != "a=v,a#1=v1,a#2=v2,a#3=v3,ab#1=v" {
+			t.Errorf("[a, b) got %s", got)
+		}
+		got = joined(ctx.NewIteratorPrefixWithKeyField("a", ""))
+		if got != "a=v,a#1=v1,a#2=v2,a#3=v3,ab#1=v" {
+			t.Errorf("prefix a got %s", got)
+		}
+
+		// the writes of the transaction are visible
+		ctx.PutState("a", "15", "new")
+		ctx.DeleteState("a", "2")
+		got = joined(ctx.NewIteratorWithField("a", "1", "3"))
+		if got != "a#1=v1,a#15=new" {
+			t.Errorf("[a#1, a#3) after writes got %s", got)
+		}
+	}, nil)
+}
+
+func TestHistory(t *testing.T) {
+	c := NewChain()
+	c.SetBlockHeight(7)
+	c.SetTxTimeStamp(1700000000)
+	c.Invoke(func() { sdk.NewSimContext().PutState("k", "f", "1") }, nil)
+	c.SetBlockHeight(8)
+	c.Invoke(func() { sdk.NewSimContext().DeleteState("k", "f") }, nil)
+
+	c.Invoke(func() {
+		it, code := sdk.NewSimContext().NewHistoryKvIterForKey("k", "f")
+		if code != sdk.SUCCESS {
+			t.Fatalf("history iterator %d", code)
+		}
+		var got []*sdk.KeyModification
+		if err := sdk.ForEachHistory(it, func(m *sdk.KeyModification) bool {
+			got = append(got, m)
+			return true
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 2 {
+			t.Fatalf("%d modifications", len(got))
+		}
+		if string(got[0].Value) != "1" || got[0].TxId != "mock_tx_1" || got[0].BlockHeight != 7 ||
+			got[0].IsDelete || got[0].Timestamp != "1700000000" {
+			t.Errorf("first modification %+v", got[0])
+		}
+		if !got[1].IsDelete || got[1].TxId != "mock_tx_2" || got[1].BlockHeight != 8 {
+			t.Errorf("second modification %+v", got[1])
+		}
+	}, nil)
+}
+
+func TestUnsupportedMethod(t *testing.T) {
+	header := sdk.NewEasyCodec()
+	header.AddValue(sdk.EasyKeyType_SYSTEM, "method", sdk.EasyValueType_STRING, "NoSuchMethod")
+
+	c := NewChain()
+	if code := c.SysCall(string(header.Marshal()), ""); code != codeError {
+		t.Fatalf("sys_call outside of a transaction returned %d", code)
+	}
+	var code int32
+	result := c.Invoke(func() {
+		code = c.SysCall(string(header.Marshal()), string(sdk.NewEasyCodec().Marshal()))
+	}, nil)
+	if code != codeError {
+		t.Fatalf("unsupported method returned %d", code)
+	}
+	if len(result.Logs) != 1 || !strings.Contains(result.Logs[0], "NoSuchMethod") {
+		t.Fatalf("logs %q", result.Logs)
+	}
+}
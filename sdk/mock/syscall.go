@@ -0,0 +1,366 @@
+//go:build !wasm
+// +build !wasm
+
+/*
+Copyright (C) BABEC. All rights reserved.
+Copyright (C) THL A29 Limited, a Tencent company. All rights reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package mock
+
+import (
+	"encoding/binary"
+	"strconv"
+	"strings"
+
+	vmPb "chainmaker.org/chainmaker/pb-go/v2/vm"
+	"github.com/TKOTKCh/contract-sdk-go-wasm/sdk"
+)
+
+const (
+	codeSuccess int32 = 0
+	codeError   int32 = 1
+)
+
+// iterator chain side handle of a kv or history iterator
+type iterator struct {
+	rows [][]byte
+	pos  int
+}
+
+// SysCall implement sdk.Host, answer the request of the sdk from memory
+func (c *Chain) SysCall(requestHeader string, requestBody string) int32 {
+	if c.tx == nil {
+		return codeError
+	}
+	header := sdk.NewEasyCodecWithBytes([]byte(requestHeader))
+	methodValue, err := header.GetValue("method", sdk.EasyKeyType_SYSTEM)
+	if err != nil {
+		return codeError
+	}
+	method, _ := methodValue.(string)
+
+	// result methods do not carry an EasyCodec body
+	switch method {
+	case sdk.ContractMethodSuccessResult:
+		if !c.tx.result.IsError {
+			c.tx.result.Payload = []byte(requestBody)
+		}
+		return codeSuccess
+	case sdk.ContractMethodErrorResult:
+		c.tx.result.IsError = true
+		c.tx.result.Payload = nil
+		c.tx.result.Message += requestBody
+		return codeSuccess
+	}
+
+	req := sdk.NewEasyCodecWithBytes([]byte(requestBody))
+	switch method {
+	case sdk.ContractMethodGetStateLen:
+		value, exists := c.get(getString(req, "key"), getString(req, "field"))
+		if ptr, err := req.GetInt32("exists_ptr"); err == nil {
+			var flag int32
+			if exists {
+				flag = 1
+			}
+			if !writeInt32At(ptr, flag) {
+				return codeError
+			}
+		}
+		return c.writeLen(req, value)
+	case sdk.ContractMethodGetState:
+		return c.writePending(req)
+	case sdk.ContractMethodPutState:
+		value, _ := req.GetBytes("value")
+		c.tx.put(&Write{Key: getString(req, "key"), Field: getString(req, "field"), Value: value})
+		return codeSuccess
+	case sdk.ContractMethodDeleteState:
+		c.tx.put(&Write{Key: getString(req, "key"), Field: getString(req, "field"), IsDelete: true})
+		return codeSuccess
+
+	case sdk.ContractMethodGetBatchStateLen:
+		keys, ok := batchKeys(req)
+		if !ok {
+			return codeError
+		}
+		for _, k := range keys.Keys {
+			k.Value, _ = c.get(k.Key, k.Field)
+		}
+		value, err := keys.Marshal()
+		if err != nil {
+			return codeError
+		}
+		return c.writeLen(req, value)
+	case sdk.ContractMethodGetBatchState:
+		return c.writePending(req)
+	case sdk.ContractMethodPutBatchState:
+		keys, ok := batchKeys(req)
+		if !ok {
+			return codeError
+		}
+		for _, k := range keys.Keys {
+			c.tx.put(&Write{Key: k.Key, Field: k.Field, Value: k.Value})
+		}
+		return codeSuccess
+	case sdk.ContractMethodDeleteBatchState:
+		keys, ok := batchKeys(req)
+		if !ok {
+			return codeError
+		}
+		for _, k := range keys.Keys {
+			c.tx.put(&Write{Key: k.Key, Field: k.Field, IsDelete: true})
+		}
+		return codeSuccess
+
+	case sdk.ContractMethodSenderAddressLen:
+		return c.writeLen(req, []byte(c.sender.Address))
+	case sdk.ContractMethodSenderAddress:
+		return c.writePending(req)
+
+	case sdk.ContractMethodEmitEvent:
+		event := &Event{Topic: getString(req, "topic")}
+		for i := 0; ; i++ {
+			data, err := req.GetString("data" + strconv.Itoa(i))
+			if err != nil {
+				break
+			}
+			event.Data = append(event.Data, data)
+		}
+		c.tx.result.Events = append(c.tx.result.Events, event)
+		return codeSuccess
+
+	case sdk.ContractMethodCallContractLen:
+		fn, ok := c.contracts[getString(req, "contract_name")]
+		if !ok {
+			return codeError
+		}
+		param, _ := req.GetBytes("param")
+		result, err := fn(getString(req, "method"), sdk.NewEasyCodecWithBytes(param).ToMap())
+		if err != nil {
+			return codeError
+		}
+		return c.writeLen(req, result)
+	case sdk.ContractMethodCallContract:
+		return c.writePending(req)
+
+	case sdk.ContractMethodKvIterator:
+		start := compositeKey(getString(req, "start_key"), getString(req, "start_field"))
+		limit := compositeKey(getString(req, "limit_key"), getString(req, "limit_field"))
+		entries := c.snapshot(func(k string) bool { return k >= start && k < limit })
+		keysOnly := false
+		if ptr, err := req.GetInt32("options_ack_ptr"); err == nil && !c.ignoreIteratorOptions {
+			entries, keysOnly = applyIteratorOptions(req, entries)
+			if !writeInt32At(ptr, 1) {
+				return codeError
+			}
+		}
+		return c.newKvIterator(req, entries, keysOnly)
+	case sdk.ContractMethodKvPreIterator:
+		prefix := compositeKey(getString(req, "start_key"), getString(req, "start_field"))
+		entries := c.snapshot(func(k string) bool { return strings.HasPrefix(k, prefix) })
+		return c.newKvIterator(req, entries, false)
+	case sdk.ContractMethodKvIteratorHasNext:
+		return c.iteratorHasNext(req, "rs_index")
+	case sdk.ContractMethodKvIteratorNextLen:
+		return c.iteratorNextLen(req, "rs_index")
+	case sdk.ContractMethodKvIteratorNext:
+		return c.iteratorNext(req, "rs_index")
+	case sdk.ContractMethodKvIteratorClose:
+		return c.iteratorClose(req, "rs_index")
+
+	case sdk.ContractHistoryKvIterator:
+		return c.newHistoryIterator(req)
+	case sdk.ContractHistoryKvIteratorHasNext:
+		return c.iteratorHasNext(req, "ks_index")
+	case sdk.ContractHistoryKvIteratorNextLen:
+		return c.iteratorNextLen(req, "ks_index")
+	case sdk.ContractHistoryKvIteratorNext:
+		return c.iteratorNext(req, "ks_index")
+	case sdk.ContractHistoryKvIteratorClose:
+		return c.iteratorClose(req, "ks_index")
+	}
+	c.tx.result.Logs = append(c.tx.result.Logs, "mock: unsupported sys_call method "+method)
+	return codeError
+}
+
+// Log implement sdk.Host
+func (c *Chain) Log(msg string) {
+	if c.tx != nil {
+		c.tx.result.Logs = append(c.tx.result.Logs, msg)
+	}
+}
+
+// LogWithType implement sdk.Host
+func (c *Chain) LogWithType(msg string, msgType int32) {
+	c.Log(msg)
+}
+
+func (c *Chain) newKvIterator(req *sdk.EasyCodec, entries []*entry, keysOnly bool) int32 {
+	rows := make([][]byte, 0, len(entries))
+	for _, e := range entries {
+		row := sdk.NewEasyCodec()
+		row.AddString("key", e.key)
+		row.AddString("field", e.field)
+		if !keysOnly {
+			row.AddBytes("value", e.value)
+		}
+		rows = append(rows, row.Marshal())
+	}
+	return c.newIterator(req, rows)
+}
+
+// applyIteratorOptions order and bound entries as the options of a KvIterator request ask,
+// return whether values are left out
+func applyIteratorOptions(req *sdk.EasyCodec, entries []*entry) ([]*entry, bool) {
+	if getInt32(req, "descending") != 0 {
+		for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+			entries[i], entries[j] = entries[j], entries[i]
+		}
+	}
+	if skip := int(getInt32(req, "skip")); skip >= len(entries) {
+		entries = entries[:0]
+	} else if skip > 0 {
+		entries = entries[skip:]
+	}
+	if maxRows := int(getInt32(req, "max_rows")); maxRows > 0 && maxRows < len(entries) {
+		entries = entries[:maxRows]
+	}
+	return entries, getInt32(req, "keys_only") != 0
+}
+
+func (c *Chain) newHistoryIterator(req *sdk.EasyCodec) int32 {
+	k := compositeKey(getString(req, "start_key"), getString(req, "start_field"))
+	rows := make([][]byte, 0, len(c.history[k]))
+	for _, m := range c.history[k] {
+		var isDelete int32
+		if m.IsDelete {
+			isDelete = 1
+		}
+		row := sdk.NewEasyCodec()
+		row.AddBytes("value", m.Value)
+		row.AddString("txId", m.TxId)
+		row.AddInt32("blockHeight", int32(m.BlockHeight))
+		row.AddInt32("isDelete", isDelete)
+		row.AddString("timestamp", m.Timestamp)
+		rows = append(rows, row.Marshal())
+	}
+	return c.newIterator(req, rows)
+}
+
+func (c *Chain) newIterator(req *sdk.EasyCodec, rows [][]byte) int32 {
+	c.tx.iterIndex++
+	c.tx.iterators[c.tx.iterIndex] = &iterator{rows: rows}
+	return c.writeInt32(req, c.tx.iterIndex)
+}
+
+func (c *Chain) iteratorHasNext(req *sdk.EasyCodec, indexKey string) int32 {
+	it, ok := c.iterator(req, indexKey)
+	if !ok {
+		return codeError
+	}
+	var hasNext int32
+	if it.pos < len(it.rows) {
+		hasNext = 1
+	}
+	return c.writeInt32(req, hasNext)
+}
+
+func (c *Chain) iteratorNextLen(req *sdk.EasyCodec, indexKey string) int32 {
+	it, ok := c.iterator(req, indexKey)
+	if !ok || it.pos >= len(it.rows) {
+		return codeError
+	}
+	return c.writeLen(req, it.rows[it.pos])
+}
+
+func (c *Chain) iteratorNext(req *sdk.EasyCodec, indexKey string) int32 {
+	it, ok := c.iterator(req, indexKey)
+	if !ok || it.pos >= len(it.rows) {
+		return codeError
+	}
+	it.pos++
+	return c.writePending(req)
+}
+
+func (c *Chain) iteratorClose(req *sdk.EasyCodec, indexKey string) int32 {
+	index, _ := req.GetInt32(indexKey)
+	if _, ok := c.tx.iterators[index]; !ok {
+		return codeError
+	}
+	delete(c.tx.iterators, index)
+	return c.writeInt32(req, 1)
+}
+
+func (c *Chain) iterator(req *sdk.EasyCodec, indexKey string) (*iterator, bool) {
+	index, err := req.GetInt32(indexKey)
+	if err != nil {
+		return nil, false
+	}
+	it, ok := c.tx.iterators[index]
+	return it, ok
+}
+
+// writeLen answer the len half of a len/data pair, the value is kept for the data half
+func (c *Chain) writeLen(req *sdk.EasyCodec, value []byte) int32 {
+	c.tx.pending = value
+	return c.writeInt32(req, int32(len(value)))
+}
+
+// writePending answer the data half of a len/data pair
+func (c *Chain) writePending(req *sdk.EasyCodec) int32 {
+	ptr, err := req.GetInt32("value_ptr")
+	if err != nil {
+		return codeError
+	}
+	mem := sdk.HostMemory(ptr)
+	if len(mem) < len(c.tx.pending) {
+		return codeError
+	}
+	copy(mem, c.tx.pending)
+	c.tx.pending = nil
+	return codeSuccess
+}
+
+func (c *Chain) writeInt32(req *sdk.EasyCodec, value int32) int32 {
+	ptr, err := req.GetInt32("value_ptr")
+	if err != nil {
+		return codeError
+	}
+	if !writeInt32At(ptr, value) {
+		return codeError
+	}
+	return codeSuccess
+}
+
+func writeInt32At(ptr int32, value int32) bool {
+	mem := sdk.HostMemory(ptr)
+	if len(mem) < 4 {
+		return false
+	}
+	binary.LittleEndian.PutUint32(mem, uint32(value))
+	return true
+}
+
+func batchKeys(req *sdk.EasyCodec) (*vmPb.BatchKeys, bool) {
+	data, err := req.GetBytes("BatchKeys")
+	if err != nil {
+		return nil, false
+	}
+	keys := &vmPb.BatchKeys{}
+	if err = keys.Unmarshal(data); err != nil {
+		return nil, false
+	}
+	return keys, true
+}
+
+func getString(ec *sdk.EasyCodec, key string) string {
+	value, _ := ec.GetString(key)
+	return value
+}
+
+func getInt32(ec *sdk.EasyCodec, key string) int32 {
+	value, _ := ec.GetInt32(key)
+	return value
+}
@@ -0,0 +1,166 @@
+/*
+Copyright (C) BABEC. All rights reserved.
+Copyright (C) THL A29 Limited, a Tencent company. All rights reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package sdk
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+const (
+	// MaxPageLimit the most rows of one Paginate page
+	MaxPageLimit = 1000
+	// cursorChecksumLen the checksum bytes ending a cursor
+	cursorChecksumLen = 16
+)
+
+// Page one page of rows of Paginate
+type Page struct {
+	// Rows in [key+"#"+field] order
+	Rows []KV
+	// Cursor of the next page, empty on the last page
+	Cursor string
+}
+
+// Paginate return up to limit rows of ctx whose [key+"#"+field] starts with prefix, after the row
+// of cursor. The first page has an empty cursor, the next ones the Cursor of the previous page.
+// ctx must be an IteratorOptionsContext, as the SimContext of the sdk, else it is ErrUnsupported.
+// A limit below 1, an empty prefix, or one of only 0xff bytes, is ErrInvalidArgument, a limit over
+// MaxPageLimit is ErrLimitExceeded.
+//
+// A cursor is url-safe and ends with a checksum over prefix and the position: a corrupted or
+// truncated cursor, or one of another prefix, is ErrCodec. The checksum is not keyed, it only
+// detects accidental corruption: anyone can build the cursor of any row under prefix, so a cursor
+// must not be trusted for more than where the next page starts.
+//
+// Rows are read with the range iterator of ctx, starting at the row of the cursor and bounded to
+// limit+2 rows by the host, so rows written between two pages are seen when they sort after the cursor
+func Paginate(ctx SimContext, prefix string, cursor string, limit int) (*Page, error) {
+	if limit <= 0 {
+		return nil, fmt.Errorf("page limit %d below 1: %w", limit, ErrInvalidArgument)
+	}
+	if limit > MaxPageLimit {
+		return nil, fmt.Errorf("page limit %d over %d: %w", limit, MaxPageLimit, ErrLimitExceeded)
+	}
+	limitKey := prefixLimit(prefix)
+	if limitKey == "" {
+		return nil, fmt.Errorf("paginate prefix %q has no upper bound, it needs a byte below 0xff: %w",
+			prefix, ErrInvalidArgument)
+	}
+	octx, ok := ctx.(IteratorOptionsContext)
+	if !ok {
+		return nil, fmt.Errorf("paginate over %T: %w", ctx, ErrUnsupported)
+	}
+	startKey, startField := prefix, ""
+	var after *KV
+	if cursor != "" {
+		key, field, err := decodeCursor(prefix, cursor)
+		if err != nil {
+			return nil, err
+		}
+		startKey, startField = key, field
+		after = &KV{Key: key, Field: field}
+	}
+
+	// the row of the cursor, limit rows and one more telling there is a next page
+	rs, code := octx.NewIteratorWithOptions(startKey, startField, limitKey, "", IteratorOptions{MaxRows: limit + 2})
+	if code != SUCCESS {
+		return nil, fmt.Errorf("paginate iterator returned %d: %w", code, ErrIterator)
+	}
+	page := &Page{Rows: make([]KV, 0, minInt(limit, 64))}
+	more := false
+	err := ForEachKV(rs, func(key string, field string, value []byte) bool {
+		// the range starts at the row of the cursor, returned by the previous page
+		if after != nil {
+			skip := key == after.Key && field == after.Field
+			after = nil
+			if skip {
+				return true
+			}
+		}
+		if len(page.Rows) == limit {
+			more = true
+			return false
+		}
+		page.Rows = append(page.Rows, KV{Key: key, Field: field, Value: value})
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if more {
+		last := page.Rows[len(page.Rows)-1]
+		page.Cursor = encodeCursor(prefix, last.Key, last.Field)
+	}
+	return page, nil
+}
+
+// prefixLimit return the smallest string above all the strings starting with prefix, empty if none
+func prefixLimit(prefix string) string {
+	b := []byte(prefix)
+	for i := len(b) - 1; i >= 0; i-- {
+		if b[i] < 0xff {
+			b[i]++
+			return string(b[:i+1])
+		}
+	}
+	return ""
+}
+
+// cursorChecksum the checksum of the cursor payload under prefix, unkeyed, against accidental corruption only
+func cursorChecksum(prefix string, payload []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte(prefix))
+	h.Write([]byte{0})
+	h.Write(payload)
+	return h.Sum(nil)[:cursorChecksumLen]
+}
+
+// encodeCursor serialize the position of the row [key, field] as base64url(EasyCodec + checksum)
+func encodeCursor(prefix string, key string, field string) string {
+	ec := NewEasyCodec()
+	ec.AddString("key", key)
+	ec.AddString("field", field)
+	payload := ec.Marshal()
+	return base64.RawURLEncoding.EncodeToString(append(payload, cursorChecksum(prefix, payload)...))
+}
+
+func decodeCursor(prefix string, cursor string) (string, string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(data) < cursorChecksumLen {
+		return "", "", fmt.Errorf("malformed cursor: %w", ErrCodec)
+	}
+	payload, sum := data[:len(data)-cursorChecksumLen], data[len(data)-cursorChecksumLen:]
+	if !bytes.Equal(sum, cursorChecksum(prefix, payload)) {
+		return "", "", fmt.Errorf("cursor checksum mismatch: %w", ErrCodec)
+	}
+	items, err := EasyUnmarshalStrict(payload)
+	if err != nil {
+		return "", "", err
+	}
+	ec := NewEasyCodecWithItems(items)
+	key, err := ec.GetString("key")
+	if err != nil {
+		return "", "", fmt.Errorf("cursor key: %v: %w", err, ErrCodec)
+	}
+	field, err := ec.GetString("field")
+	if err != nil {
+		return "", "", fmt.Errorf("cursor field: %v: %w", err, ErrCodec)
+	}
+	composite := key
+	if field != "" {
+		composite = key + "#" + field
+	}
+	if !strings.HasPrefix(composite, prefix) {
+		return "", "", fmt.Errorf("cursor outside of prefix %q: %w", prefix, ErrCodec)
+	}
+	return key, field, nil
+}
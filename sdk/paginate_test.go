@@ -0,0 +1,164 @@
+//go:build !wasm
+// +build !wasm
+
+/*
+Copyright (C) BABEC. All rights reserved.
+Copyright (C) THL A29 Limited, a Tencent company. All rights reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package sdk_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/TKOTKCh/contract-sdk-go-wasm/sdk"
+	"github.com/TKOTKCh/contract-sdk-go-wasm/sdk/mock"
+)
+
+// pageRows return the key#field rows of page
+func pageRows(page *sdk.Page) string {
+	var rows []string
+	for _, kv := range page.Rows {
+		rows = append(rows, kv.Key+"#"+kv.Field)
+	}
+	return strings.Join(rows, ",")
+}
+
+func paginated() *mock.Chain {
+	chain := mock.NewChain()
+	for _, field := range []string{"1", "2", "3", "4", "5"} {
+		chain.SetState("item", field, []byte("v"+field))
+	}
+	chain.SetState("items", "1", []byte("other key"))
+	chain.SetState("iteM", "1", []byte("before the prefix"))
+	return chain
+}
+
+func TestPaginateAllPages(t *testing.T) {
+	paginated().Invoke(func() {
+		ctx := sdk.NewSimContext()
+		var got []string
+		cursor := ""
+		for i := 0; ; i++ {
+			page, err := sdk.Paginate(ctx, "item#", cursor, 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, pageRows(page))
+			if page.Cursor == "" {
+				break
+			}
+			if i == 5 {
+				t.Fatal("the pages do not end")
+			}
+			cursor = page.Cursor
+		}
+		want := []string{"item#1,item#2", "item#3,item#4", "item#5"}
+		if strings.Join(got, "|") != strings.Join(want, "|") {
+			t.Fatalf("pages %q, want %q", got, want)
+		}
+	}, nil)
+}
+
+// maxRowsRecorder Host recording the max_rows of each KvIterator sys_call
+type maxRowsRecorder struct {
+	sdk.Host
+	maxRows []int32
+}
+
+func (h *maxRowsRecorder) SysCall(requestHeader string, requestBody string) int32 {
+	header := sdk.NewEasyCodecWithItems(sdk.EasyUnmarshal([]byte(requestHeader)))
+	if method, _ := header.GetValue("method", sdk.EasyKeyType_SYSTEM); method == sdk.ContractMethodKvIterator {
+		maxRows, _ := sdk.NewEasyCodecWithItems(sdk.EasyUnmarshal([]byte(requestBody))).GetInt32("max_rows")
+		h.maxRows = append(h.maxRows, maxRows)
+	}
+	return h.Host.SysCall(requestHeader, requestBody)
+}
+
+func TestPaginateBoundsTheHostScan(t *testing.T) {
+	paginated().Invoke(func() {
+		h := &maxRowsRecorder{}
+		h.Host = sdk.SetHost(h)
+		page, err := sdk.Paginate(sdk.NewSimContext(), "item#", "", 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = sdk.Paginate(sdk.NewSimContext(), "item#", page.Cursor, 2); err != nil {
+			t.Fatal(err)
+		}
+		if len(h.maxRows) != 2 || h.maxRows[0] != 4 || h.maxRows[1] != 4 {
+			t.Fatalf("max_rows %v, want the cursor row, the page and one more", h.maxRows)
+		}
+	}, nil)
+}
+
+func TestPaginateSeesCachedWrites(t *testing.T) {
+	paginated().Invoke(func() {
+		ctx := sdk.NewCachedSimContext()
+		ctx.PutState("item", "25", "new")
+		ctx.DeleteState("item", "1")
+		first, err := sdk.Paginate(ctx, "item#", "", 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := pageRows(first); got != "item#2,item#25" {
+			t.Fatalf("first page %s", got)
+		}
+		// a row written between two pages after the cursor is seen
+		ctx.PutState("item", "26", "new")
+		second, err := sdk.Paginate(ctx, "item#", first.Cursor, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := pageRows(second); got != "item#26,item#3" {
+			t.Fatalf("second page %s", got)
+		}
+	}, nil)
+}
+
+func TestPaginateErrors(t *testing.T) {
+	paginated().Invoke(func() {
+		ctx := sdk.NewSimContext()
+		page, err := sdk.Paginate(ctx, "item#", "", 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cursor := page.Cursor
+
+		for _, limit := range []int{0, -1} {
+			if _, err = sdk.Paginate(ctx, "item#", "", limit); !errors.Is(err, sdk.ErrInvalidArgument) {
+				t.Errorf("limit %d: got %v, want ErrInvalidArgument", limit, err)
+			}
+		}
+		if _, err = sdk.Paginate(ctx, "item#", "", sdk.MaxPageLimit+1); !errors.Is(err, sdk.ErrLimitExceeded) {
+			t.Errorf("limit over MaxPageLimit: got %v, want ErrLimitExceeded", err)
+		}
+		for _, prefix := range []string{"", "\xff\xff"} {
+			if _, err = sdk.Paginate(ctx, prefix, "", 1); !errors.Is(err, sdk.ErrInvalidArgument) {
+				t.Errorf("prefix %q: got %v, want ErrInvalidArgument", prefix, err)
+			}
+		}
+		corrupted := []byte(cursor)
+		corrupted[2] ^= 1
+		for _, c := range []string{string(corrupted), cursor[:len(cursor)-1], "!"} {
+			if _, err = sdk.Paginate(ctx, "item#", c, 1); !errors.Is(err, sdk.ErrCodec) {
+				t.Errorf("cursor %q: got %v, want ErrCodec", c, err)
+			}
+		}
+		if _, err = sdk.Paginate(ctx, "items#", cursor, 1); !errors.Is(err, sdk.ErrCodec) {
+			t.Errorf("cursor of another prefix: got %v, want ErrCodec", err)
+		}
+		if _, err = sdk.Paginate(plainContext{ctx}, "item#", "", 1); !errors.Is(err, sdk.ErrUnsupported) {
+			t.Errorf("context without iterator options: got %v, want ErrUnsupported", err)
+		}
+	}, nil)
+}
+
+// plainContext SimContext implemented outside of the sdk
+type plainContext struct {
+	sdk.SimContext
+}
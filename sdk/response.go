@@ -0,0 +1,93 @@
+/*
+Copyright (C) BABEC. All rights reserved.
+Copyright (C) THL A29 Limited, a Tencent company. All rights reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package sdk
+
+const (
+	// StatusOK status of a successful Response
+	StatusOK int32 = 200
+	// StatusError default status of a failed Response
+	StatusError int32 = 500
+)
+
+// Response the result of a contract method, mirror of protogo.Response in the other chainmaker sdks.
+// A Contract reports the Response of a method once, after the handler returned. The chain only
+// records whether the method failed, with the Payload of a success or the Message of a failure:
+// Status is not reported, it only tells IsError
+type Response struct {
+	Status  int32
+	Message string
+	Payload []byte
+}
+
+// Success return a successful Response carrying payload
+func Success(payload []byte) Response {
+	return Response{Status: StatusOK, Payload: payload}
+}
+
+// Error return a failed Response with status code and msg, a code of StatusOK is StatusError
+func Error(code int32, msg string) Response {
+	if code == StatusOK {
+		code = StatusError
+	}
+	return Response{Status: code, Message: msg}
+}
+
+// IsError return whether r is a failed Response
+func (r Response) IsError() bool {
+	return r.Status != StatusOK
+}
+
+// report record r as the execution result of the transaction
+func (r Response) report() {
+	if r.IsError() {
+		sysCall(getRequestHeader(ContractMethodErrorResult), r.Message)
+		return
+	}
+	sysCall(getRequestHeader(ContractMethodSuccessResult), string(r.Payload))
+}
+
+// resultRecorder collect SuccessResult and ErrorResult calls made while a Contract method runs,
+// so that the result is reported once with the rules of the chain: success overrides success,
+// errors append, and nothing overrides an error
+type resultRecorder struct {
+	success bool
+	payload []byte
+	failed  bool
+	message string
+}
+
+// recorder is set while a Contract method runs, nil otherwise
+var recorder *resultRecorder
+
+func (r *resultRecorder) recordSuccess(payload []byte) {
+	if r.failed {
+		return
+	}
+	r.success = true
+	r.payload = payload
+}
+
+func (r *resultRecorder) recordError(msg string) {
+	r.failed = true
+	r.message += msg
+}
+
+// merge combine the recorded results with the Response returned by the handler,
+// a successful Response without payload keeps the recorded payload
+func (r *resultRecorder) merge(response Response) Response {
+	if !r.failed {
+		if r.success && !response.IsError() && response.Payload == nil {
+			response.Payload = r.payload
+		}
+		return response
+	}
+	if response.IsError() {
+		return Error(response.Status, r.message+response.Message)
+	}
+	return Error(StatusError, r.message)
+}
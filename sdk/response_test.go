@@ -0,0 +1,61 @@
+/*
+Copyright (C) BABEC. All rights reserved.
+Copyright (C) THL A29 Limited, a Tencent company. All rights reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package sdk
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestErrorWithStatusOKFails(t *testing.T) {
+	if r := Error(StatusOK, "bad"); !r.IsError() || r.Status != StatusError {
+		t.Fatalf("got %+v", r)
+	}
+	if r := Error(403, "denied"); !r.IsError() || r.Status != 403 {
+		t.Fatalf("got %+v", r)
+	}
+}
+
+func TestResultRecorderMerge(t *testing.T) {
+	tests := []struct {
+		name     string
+		record   func(r *resultRecorder)
+		response Response
+		want     Response
+	}{
+		{"nothing recorded", func(r *resultRecorder) {}, Success([]byte("p")), Success([]byte("p"))},
+		{"recorded payload kept", func(r *resultRecorder) {
+			r.recordSuccess([]byte("a"))
+			r.recordSuccess([]byte("b"))
+		}, Success(nil), Success([]byte("b"))},
+		{"returned payload wins", func(r *resultRecorder) {
+			r.recordSuccess([]byte("a"))
+		}, Success([]byte("p")), Success([]byte("p"))},
+		{"returned error wins over success", func(r *resultRecorder) {
+			r.recordSuccess([]byte("a"))
+		}, Error(403, "denied"), Error(403, "denied")},
+		{"recorded error wins over success", func(r *resultRecorder) {
+			r.recordError("bad")
+		}, Success([]byte("p")), Error(StatusError, "bad")},
+		{"success after error ignored", func(r *resultRecorder) {
+			r.recordError("bad")
+			r.recordSuccess([]byte("a"))
+		}, Success(nil), Error(StatusError, "bad")},
+		{"errors append", func(r *resultRecorder) {
+			r.recordError("a;")
+			r.recordError("b;")
+		}, Error(403, "c"), Error(403, "a;b;c")},
+	}
+	for _, tt := range tests {
+		r := &resultRecorder{}
+		tt.record(r)
+		if got := r.merge(tt.response); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
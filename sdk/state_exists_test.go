@@ -0,0 +1,100 @@
+//go:build !wasm
+// +build !wasm
+
+/*
+Copyright (C) BABEC. All rights reserved.
+Copyright (C) THL A29 Limited, a Tencent company. All rights reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package sdk_test
+
+import (
+	"testing"
+
+	"github.com/TKOTKCh/contract-sdk-go-wasm/sdk"
+	"github.com/TKOTKCh/contract-sdk-go-wasm/sdk/mock"
+)
+
+// oldHost Host of a chain that does not know exists_ptr, it drops the arg before the wrapped Host
+type oldHost struct {
+	sdk.Host
+}
+
+func (h oldHost) SysCall(requestHeader string, requestBody string) int32 {
+	var items []*sdk.EasyCodecItem
+	for _, item := range sdk.EasyUnmarshal([]byte(requestBody)) {
+		if item.Key != "exists_ptr" {
+			items = append(items, item)
+		}
+	}
+	return h.Host.SysCall(requestHeader, string(sdk.NewEasyCodecWithItems(items).Marshal()))
+}
+
+// existing chain holding an empty and a non empty value
+func existing() *mock.Chain {
+	chain := mock.NewChain()
+	chain.SetState("k", "empty", []byte{})
+	chain.SetState("k", "full", []byte("v"))
+	chain.SetState("solo", "", []byte{})
+	return chain
+}
+
+func TestGetStateWithExists(t *testing.T) {
+	existing().Invoke(func() {
+		if value, ok, err := sdk.GetStateWithExistsE("k", "empty"); value == nil || len(value) != 0 || !ok || err != nil {
+			t.Errorf("empty: %v, %v, %v", value, ok, err)
+		}
+		if value, ok, err := sdk.GetStateWithExistsE("k", "full"); string(value) != "v" || !ok || err != nil {
+			t.Errorf("full: %q, %v, %v", value, ok, err)
+		}
+		if value, ok, err := sdk.GetStateWithExistsE("k", "missing"); value != nil || ok || err != nil {
+			t.Errorf("missing: %v, %v, %v", value, ok, err)
+		}
+		for _, ctx := range []sdk.SimContext{sdk.NewSimContext(), sdk.NewCachedSimContext()} {
+			if _, ok, code := ctx.GetStateFromKeyWithExists("solo"); !ok || code != sdk.SUCCESS {
+				t.Errorf("%T: empty key without field reported missing", ctx)
+			}
+			if _, ok, code := ctx.GetStateFromKeyWithExists("nobody"); ok || code != sdk.SUCCESS {
+				t.Errorf("%T: missing key without field reported existing", ctx)
+			}
+		}
+	}, nil)
+}
+
+func TestGetStateWithExistsOnOldHost(t *testing.T) {
+	existing().Invoke(func() {
+		sdk.SetHost(oldHost{sdk.SetHost(nil)})
+		// without exists_ptr an empty value can not be told from a missing key
+		if _, ok, err := sdk.GetStateWithExistsE("k", "empty"); ok || err != nil {
+			t.Errorf("empty: %v, %v", ok, err)
+		}
+		if value, ok, err := sdk.GetStateWithExistsE("k", "full"); string(value) != "v" || !ok || err != nil {
+			t.Errorf("full: %q, %v, %v", value, ok, err)
+		}
+		if _, ok, err := sdk.GetStateWithExistsE("k", "missing"); ok || err != nil {
+			t.Errorf("missing: %v, %v", ok, err)
+		}
+	}, nil)
+}
+
+func TestIteratorValuesNotNil(t *testing.T) {
+	existing().Invoke(func() {
+		rs, code := sdk.NewSimContext().NewIteratorPrefixWithKey("k")
+		if code != sdk.SUCCESS {
+			t.Fatalf("new iterator %d", code)
+		}
+		n := 0
+		err := sdk.ForEachKV(rs, func(key string, field string, value []byte) bool {
+			if value == nil {
+				t.Errorf("%s#%s has a nil value", key, field)
+			}
+			n++
+			return true
+		})
+		if err != nil || n != 2 {
+			t.Fatalf("%d rows, %v", n, err)
+		}
+	}, nil)
+}
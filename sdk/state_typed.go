@@ -0,0 +1,169 @@
+/*
+Copyright (C) BABEC. All rights reserved.
+Copyright (C) THL A29 Limited, a Tencent company. All rights reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package sdk
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+)
+
+// typed state accessors, the stored encodings are stable and readable by other sdks:
+//   int64/uint64/big.Int: base 10 string, as strconv.FormatInt
+//   bool:                 "true" or "false"
+//   proto message:        the bytes of msg.Marshal()
+// Getters return false when [key, field] does not exist, the value is then the zero value
+
+// ProtoMessage protobuf message with gogo style Marshal and Unmarshal, as the messages of pb-go
+type ProtoMessage interface {
+	Marshal() ([]byte, error)
+	Unmarshal(data []byte) error
+}
+
+// GetStateInt64 get [key, field] stored by PutStateInt64
+func GetStateInt64(ctx SimContext, key string, field string) (int64, bool, error) {
+	value, ok, err := AsStateContextE(ctx).GetStateWithExistsE(key, field)
+	if err != nil || !ok {
+		return 0, false, err
+	}
+	i, err := strconv.ParseInt(string(value), 10, 64)
+	if err != nil {
+		return 0, true, typedStateError(key, field, "int64", err)
+	}
+	return i, true, nil
+}
+
+// PutStateInt64 put [key, field] as base 10 string
+func PutStateInt64(ctx SimContext, key string, field string, value int64) error {
+	return AsStateContextE(ctx).PutStateByteE(key, field, []byte(strconv.FormatInt(value, 10)))
+}
+
+// GetStateUint64 get [key, field] stored by PutStateUint64
+func GetStateUint64(ctx SimContext, key string, field string) (uint64, bool, error) {
+	value, ok, err := AsStateContextE(ctx).GetStateWithExistsE(key, field)
+	if err != nil || !ok {
+		return 0, false, err
+	}
+	i, err := strconv.ParseUint(string(value), 10, 64)
+	if err != nil {
+		return 0, true, typedStateError(key, field, "uint64", err)
+	}
+	return i, true, nil
+}
+
+// PutStateUint64 put [key, field] as base 10 string
+func PutStateUint64(ctx SimContext, key string, field string, value uint64) error {
+	return AsStateContextE(ctx).PutStateByteE(key, field, []byte(strconv.FormatUint(value, 10)))
+}
+
+// GetStateBigInt get [key, field] stored by PutStateBigInt, nil when not exists
+func GetStateBigInt(ctx SimContext, key string, field string) (*big.Int, bool, error) {
+	value, ok, err := AsStateContextE(ctx).GetStateWithExistsE(key, field)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	i, ok := new(big.Int).SetString(string(value), 10)
+	if !ok {
+		return nil, true, typedStateError(key, field, "big.Int", fmt.Errorf("invalid number %q", value))
+	}
+	return i, true, nil
+}
+
+// PutStateBigInt put [key, field] as base 10 string
+func PutStateBigInt(ctx SimContext, key string, field string, value *big.Int) error {
+	if value == nil {
+		return fmt.Errorf("put nil big.Int to %s: %w", key, ErrCodec)
+	}
+	return AsStateContextE(ctx).PutStateByteE(key, field, []byte(value.String()))
+}
+
+// GetStateBool get [key, field] stored by PutStateBool
+func GetStateBool(ctx SimContext, key string, field string) (bool, bool, error) {
+	value, ok, err := AsStateContextE(ctx).GetStateWithExistsE(key, field)
+	if err != nil || !ok {
+		return false, false, err
+	}
+	switch string(value) {
+	case "true":
+		return true, true, nil
+	case "false":
+		return false, true, nil
+	}
+	return false, true, typedStateError(key, field, "bool", fmt.Errorf("invalid bool %q", value))
+}
+
+// PutStateBool put [key, field] as "true" or "false"
+func PutStateBool(ctx SimContext, key string, field string, value bool) error {
+	return AsStateContextE(ctx).PutStateByteE(key, field, []byte(strconv.FormatBool(value)))
+}
+
+// GetStateProto unmarshal [key, field] into msg, msg is untouched when not exists
+func GetStateProto(ctx SimContext, key string, field string, msg ProtoMessage) (bool, error) {
+	value, ok, err := AsStateContextE(ctx).GetStateWithExistsE(key, field)
+	if err != nil || !ok {
+		return false, err
+	}
+	if err = msg.Unmarshal(value); err != nil {
+		return true, typedStateError(key, field, "proto message", err)
+	}
+	return true, nil
+}
+
+// PutStateProto put the marshaled msg to [key, field]
+func PutStateProto(ctx SimContext, key string, field string, msg ProtoMessage) error {
+	value, err := msg.Marshal()
+	if err != nil {
+		return fmt.Errorf("marshal %s#%s: %v: %w", key, field, err, ErrCodec)
+	}
+	return AsStateContextE(ctx).PutStateByteE(key, field, value)
+}
+
+// AsStateContextE return ctx as a StateContextE, so that its failures are errors. The SimContexts of
+// the sdk are returned as they are, their errors are the ones of the sys_calls. A SimContext implemented
+// outside of the sdk only tells a ResultCode, its failures are errors naming the method and [key, field]
+func AsStateContextE(ctx SimContext) StateContextE {
+	if c, ok := ctx.(StateContextE); ok {
+		return c
+	}
+	return stateContextE{ctx}
+}
+
+// stateContextE StateContextE of a SimContext implemented outside of the sdk
+type stateContextE struct {
+	SimContext
+}
+
+func (c stateContextE) GetStateWithExistsE(key string, field string) ([]byte, bool, error) {
+	value, exists, code := c.GetStateWithExists(key, field)
+	if code != SUCCESS {
+		return nil, false, c.error("GetStateWithExists", key, field, code)
+	}
+	return []byte(value), exists, nil
+}
+
+func (c stateContextE) PutStateByteE(key string, field string, value []byte) error {
+	if code := c.PutStateByte(key, field, value); code != SUCCESS {
+		return c.error("PutStateByte", key, field, code)
+	}
+	return nil
+}
+
+func (c stateContextE) DeleteStateE(key string, field string) error {
+	if code := c.DeleteState(key, field); code != SUCCESS {
+		return c.error("DeleteState", key, field, code)
+	}
+	return nil
+}
+
+func (c stateContextE) error(method string, key string, field string, code ResultCode) error {
+	return fmt.Errorf("%T.%s %s#%s returned %d", c.SimContext, method, key, field, code)
+}
+
+func typedStateError(key string, field string, typ string, err error) error {
+	return fmt.Errorf("state %s#%s is not %s: %v: %w", key, field, typ, err, ErrCodec)
+}
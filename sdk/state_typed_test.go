@@ -0,0 +1,142 @@
+//go:build !wasm
+// +build !wasm
+
+/*
+Copyright (C) BABEC. All rights reserved.
+Copyright (C) THL A29 Limited, a Tencent company. All rights reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package sdk_test
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/TKOTKCh/contract-sdk-go-wasm/sdk"
+	"github.com/TKOTKCh/contract-sdk-go-wasm/sdk/mock"
+)
+
+// textMessage ProtoMessage marshaled as its text, failing on "bad"
+type textMessage struct {
+	text string
+}
+
+func (m *textMessage) Marshal() ([]byte, error) {
+	return []byte(m.text), nil
+}
+
+func (m *textMessage) Unmarshal(data []byte) error {
+	if string(data) == "bad" {
+		return errors.New("bad message")
+	}
+	m.text = string(data)
+	return nil
+}
+
+func TestTypedStateRoundTrip(t *testing.T) {
+	for _, ctx := range []sdk.SimContext{sdk.NewSimContext(), sdk.NewCachedSimContext()} {
+		mock.NewChain().Invoke(func() {
+			if err := sdk.PutStateInt64(ctx, "n", "i", math.MinInt64); err != nil {
+				t.Fatal(err)
+			}
+			if i, ok, err := sdk.GetStateInt64(ctx, "n", "i"); i != math.MinInt64 || !ok || err != nil {
+				t.Errorf("%T int64: %d, %v, %v", ctx, i, ok, err)
+			}
+			sdk.PutStateUint64(ctx, "n", "u", math.MaxUint64)
+			if u, ok, err := sdk.GetStateUint64(ctx, "n", "u"); u != math.MaxUint64 || !ok || err != nil {
+				t.Errorf("%T uint64: %d, %v, %v", ctx, u, ok, err)
+			}
+			huge, _ := new(big.Int).SetString("-123456789012345678901234567890", 10)
+			sdk.PutStateBigInt(ctx, "n", "b", huge)
+			if b, ok, err := sdk.GetStateBigInt(ctx, "n", "b"); !ok || err != nil || b.Cmp(huge) != 0 {
+				t.Errorf("%T big.Int: %v, %v, %v", ctx, b, ok, err)
+			}
+			sdk.PutStateBool(ctx, "n", "f", false)
+			if b, ok, err := sdk.GetStateBool(ctx, "n", "f"); b || !ok || err != nil {
+				t.Errorf("%T bool: %v, %v, %v", ctx, b, ok, err)
+			}
+			sdk.PutStateProto(ctx, "n", "m", &textMessage{"hello"})
+			var msg textMessage
+			if ok, err := sdk.GetStateProto(ctx, "n", "m", &msg); !ok || err != nil || msg.text != "hello" {
+				t.Errorf("%T proto: %q, %v, %v", ctx, msg.text, ok, err)
+			}
+		}, nil)
+	}
+}
+
+func TestTypedStateMissingAndInvalid(t *testing.T) {
+	chain := mock.NewChain()
+	chain.SetState("n", "text", []byte("x"))
+	chain.SetState("n", "empty", []byte{})
+	chain.SetState("n", "bad", []byte("bad"))
+	chain.Invoke(func() {
+		ctx := sdk.NewSimContext()
+		if i, ok, err := sdk.GetStateInt64(ctx, "n", "missing"); i != 0 || ok || err != nil {
+			t.Errorf("missing int64: %d, %v, %v", i, ok, err)
+		}
+		if b, ok, err := sdk.GetStateBigInt(ctx, "n", "missing"); b != nil || ok || err != nil {
+			t.Errorf("missing big.Int: %v, %v, %v", b, ok, err)
+		}
+		msg := textMessage{"kept"}
+		if ok, err := sdk.GetStateProto(ctx, "n", "missing", &msg); ok || err != nil || msg.text != "kept" {
+			t.Errorf("missing proto: %q, %v, %v", msg.text, ok, err)
+		}
+		if _, ok, err := sdk.GetStateInt64(ctx, "n", "text"); !ok || !errors.Is(err, sdk.ErrCodec) {
+			t.Errorf("text as int64: %v, %v", ok, err)
+		}
+		if _, ok, err := sdk.GetStateUint64(ctx, "n", "empty"); !ok || !errors.Is(err, sdk.ErrCodec) {
+			t.Errorf("empty as uint64: %v, %v", ok, err)
+		}
+		if _, ok, err := sdk.GetStateBool(ctx, "n", "text"); !ok || !errors.Is(err, sdk.ErrCodec) {
+			t.Errorf("text as bool: %v, %v", ok, err)
+		}
+		if ok, err := sdk.GetStateProto(ctx, "n", "bad", &msg); !ok || !errors.Is(err, sdk.ErrCodec) {
+			t.Errorf("bad proto: %v, %v", ok, err)
+		}
+		if err := sdk.PutStateBigInt(ctx, "n", "b", nil); !errors.Is(err, sdk.ErrCodec) {
+			t.Errorf("nil big.Int: %v", err)
+		}
+	}, nil)
+}
+
+// failingContext SimContext implemented outside of the sdk whose state calls fail
+type failingContext struct {
+	sdk.SimContext
+}
+
+func (failingContext) GetStateWithExists(key string, field string) (string, bool, sdk.ResultCode) {
+	return "", false, sdk.ERROR
+}
+
+func (failingContext) PutStateByte(key string, field string, value []byte) sdk.ResultCode {
+	return sdk.ERROR
+}
+
+func (failingContext) DeleteState(key string, field string) sdk.ResultCode {
+	return sdk.ERROR
+}
+
+func TestAsStateContextE(t *testing.T) {
+	ctx := sdk.NewSimContext()
+	if sdk.AsStateContextE(ctx) != ctx.(sdk.StateContextE) {
+		t.Fatal("the sdk SimContext was adapted")
+	}
+	c := sdk.AsStateContextE(failingContext{ctx})
+	if _, _, err := c.GetStateWithExistsE("n", "i"); err == nil || !strings.Contains(err.Error(), "GetStateWithExists n#i") {
+		t.Errorf("get: %v", err)
+	}
+	if err := c.PutStateByteE("n", "i", nil); err == nil || !strings.Contains(err.Error(), "PutStateByte n#i") {
+		t.Errorf("put: %v", err)
+	}
+	if err := c.DeleteStateE("n", "i"); err == nil || !strings.Contains(err.Error(), "DeleteState n#i") {
+		t.Errorf("delete: %v", err)
+	}
+	if _, _, err := sdk.GetStateInt64(failingContext{ctx}, "n", "i"); err == nil {
+		t.Error("typed getter ignored the failure")
+	}
+}